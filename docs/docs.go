@@ -6,10 +6,10 @@ import "github.com/swaggo/swag/v2"
 
 const docTemplate = `{
     "schemes": {{ marshal .Schemes }},
-    "components": {"schemas":{"handlers.createRecitationDTO":{"properties":{"slug":{"type":"string"}},"type":"object"},"handlers.loginDTO":{"properties":{"password":{"maxLength":64,"minLength":3,"type":"string"},"username":{"maxLength":64,"minLength":3,"type":"string"}},"required":["password","username"],"type":"object"},"handlers.registerDTO":{"properties":{"password":{"maxLength":64,"minLength":3,"type":"string"},"username":{"maxLength":64,"minLength":3,"type":"string"}},"required":["password","username"],"type":"object"},"handlers.updateRecitationDTO":{"properties":{"slug":{"type":"string"}},"type":"object"},"handlers.updateUserDTO":{"properties":{"displayname":{"type":"string"}},"type":"object"},"models.Error":{"properties":{"error":{"type":"string"},"message":{"type":"string"}},"type":"object"},"models.Segment":{"properties":{"end":{"type":"number"},"score":{"type":"number"},"start":{"type":"number"},"text":{"type":"string"}},"type":"object"},"models.Timing":{"properties":{"segments":{"items":{"$ref":"#/components/schemas/models.Segment"},"type":"array","uniqueItems":false},"text":{"type":"string"}},"type":"object"},"sqlc.AuthInsertUserRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.AuthSelectUserRow":{"properties":{"password":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.Recitation":{"properties":{"name":{"type":"string"},"reciter":{"type":"string"},"slug":{"type":"string"}},"type":"object"},"sqlc.RecitationFile":{"properties":{"has_timings":{"type":"boolean"},"lafzize_processing":{"type":"boolean"},"reciter":{"type":"string"},"slug":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"sqlc.RecitationFileUpdateRecitationFileParams":{"properties":{"has_timings":{"type":"boolean"},"lafzize_processing":{"type":"boolean"},"reciter":{"type":"string"},"slug":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"sqlc.Session":{"properties":{"csrf_token":{"type":"string"},"session_token":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.UserDeleteUserRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.UserSelectUsersRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.UserUpdateUserRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"}}},
+    "components": {"schemas":{"events.Event":{"properties":{"error":{"type":"string"},"reciter":{"type":"string"},"slug":{"type":"string"},"type":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"fsck.Issue":{"properties":{"detail":{"type":"string"},"error":{"type":"string"},"kind":{"type":"string"},"path":{"type":"string"},"repaired":{"type":"boolean"}},"type":"object"},"fsck.Report":{"properties":{"issues":{"items":{"$ref":"#/components/schemas/fsck.Issue"},"type":"array","uniqueItems":false},"repaired":{"type":"integer"}},"type":"object"},"handlers.chapterCoverageDTO":{"properties":{"chapter":{"type":"integer"},"missing":{"items":{"type":"string"},"type":"array","uniqueItems":false},"pending_lafzize":{"type":"integer"},"percentage":{"type":"number"},"timings_percentage":{"type":"number"},"uploaded":{"type":"integer"},"verses":{"type":"integer"},"with_timings":{"type":"integer"}},"type":"object"},"handlers.coverageDTO":{"properties":{"chapters":{"items":{"$ref":"#/components/schemas/handlers.chapterCoverageDTO"},"type":"array","uniqueItems":false},"juzs":{"items":{"$ref":"#/components/schemas/handlers.juzCoverageDTO"},"type":"array","uniqueItems":false},"pending_lafzize":{"type":"integer"},"percentage":{"type":"number"},"timings_percentage":{"type":"number"},"uploaded":{"type":"integer"},"verses":{"type":"integer"},"with_timings":{"type":"integer"}},"type":"object"},"handlers.createRecitationDTO":{"properties":{"slug":{"type":"string"}},"type":"object"},"handlers.importFileDTO":{"properties":{"error":{"type":"string"},"kind":{"type":"string"},"name":{"type":"string"},"status":{"type":"string"},"validation_errors":{"items":{"$ref":"#/components/schemas/models.SegmentError"},"type":"array","uniqueItems":false},"verse_key":{"type":"string"}},"type":"object"},"handlers.importSummaryDTO":{"properties":{"created_recitation":{"type":"boolean"},"failed":{"type":"integer"},"files":{"items":{"$ref":"#/components/schemas/handlers.importFileDTO"},"type":"array","uniqueItems":false},"imported":{"type":"integer"},"recitation":{"$ref":"#/components/schemas/sqlc.Recitation"},"skipped":{"type":"integer"}},"type":"object"},"handlers.juzCoverageDTO":{"properties":{"juz":{"type":"integer"},"pending_lafzize":{"type":"integer"},"percentage":{"type":"number"},"timings_percentage":{"type":"number"},"uploaded":{"type":"integer"},"verses":{"type":"integer"},"with_timings":{"type":"integer"}},"type":"object"},"handlers.lafzizeBatchDTO":{"properties":{"batch":{"$ref":"#/components/schemas/sqlc.LafzizeBatch"},"cancelled":{"type":"integer"},"errors":{"additionalProperties":{"type":"string"},"description":"Errors maps the verse keys that could not be scheduled to the reason.","type":"object"},"failed":{"type":"integer"},"queued":{"type":"integer"},"running":{"type":"integer"},"skipped":{"description":"Skipped lists the verse keys that were not scheduled because their audio\nis not transcoded yet or they are already being lafzized.","items":{"type":"string"},"type":"array","uniqueItems":false},"succeeded":{"type":"integer"},"total":{"type":"integer"}},"type":"object"},"handlers.lafzizeRecitationDTO":{"properties":{"chapter":{"maximum":114,"minimum":0,"type":"integer"},"from":{"type":"string"},"only_missing":{"type":"boolean"},"to":{"type":"string"}},"type":"object"},"handlers.loginDTO":{"properties":{"password":{"maxLength":64,"minLength":3,"type":"string"},"username":{"maxLength":64,"minLength":3,"type":"string"}},"required":["password","username"],"type":"object"},"handlers.quranAudioFileDTO":{"properties":{"segments":{"items":{"items":{"format":"int64","type":"integer"},"type":"array","uniqueItems":false},"type":"array","uniqueItems":false},"url":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"handlers.quranAudioFilesDTO":{"properties":{"audio_files":{"items":{"$ref":"#/components/schemas/handlers.quranAudioFileDTO"},"type":"array","uniqueItems":false},"pagination":{"$ref":"#/components/schemas/handlers.quranPaginationDTO"}},"type":"object"},"handlers.quranChapterAudioFileDTO":{"properties":{"audio_url":{"type":"string"},"chapter_id":{"type":"integer"},"file_size":{"type":"integer"},"format":{"type":"string"},"id":{"type":"integer"}},"type":"object"},"handlers.quranChapterAudioFileResponseDTO":{"properties":{"audio_file":{"$ref":"#/components/schemas/handlers.quranChapterAudioFileDTO"}},"type":"object"},"handlers.quranChapterAudioFilesDTO":{"properties":{"audio_files":{"items":{"$ref":"#/components/schemas/handlers.quranChapterAudioFileDTO"},"type":"array","uniqueItems":false}},"type":"object"},"handlers.quranPaginationDTO":{"properties":{"current_page":{"type":"integer"},"next_page":{"type":"integer"},"per_page":{"type":"integer"},"total_pages":{"type":"integer"},"total_records":{"type":"integer"}},"type":"object"},"handlers.quranRecitationDTO":{"properties":{"id":{"type":"integer"},"reciter_name":{"type":"string"},"style":{"type":"string"},"translated_name":{"$ref":"#/components/schemas/handlers.quranTranslatedNameDTO"}},"type":"object"},"handlers.quranRecitationsDTO":{"properties":{"recitations":{"items":{"$ref":"#/components/schemas/handlers.quranRecitationDTO"},"type":"array","uniqueItems":false}},"type":"object"},"handlers.quranTranslatedNameDTO":{"properties":{"language_name":{"type":"string"},"name":{"type":"string"}},"type":"object"},"handlers.recitationDTO":{"properties":{"auto_lafzize":{"$ref":"#/components/schemas/sql.NullBool"},"coverage":{"type":"number"},"name":{"type":"string"},"reciter":{"type":"string"},"slug":{"type":"string"},"timings_coverage":{"type":"number"}},"type":"object"},"handlers.registerDTO":{"properties":{"password":{"maxLength":64,"minLength":3,"type":"string"},"username":{"maxLength":64,"minLength":3,"type":"string"}},"required":["password","username"],"type":"object"},"handlers.splitSummaryDTO":{"properties":{"chapter":{"type":"integer"},"failed":{"type":"integer"},"imported":{"type":"integer"},"skipped":{"type":"integer"},"verses":{"items":{"$ref":"#/components/schemas/handlers.splitVerseDTO"},"type":"array","uniqueItems":false}},"type":"object"},"handlers.splitVerseDTO":{"properties":{"end":{"type":"number"},"error":{"type":"string"},"has_timings":{"type":"boolean"},"start":{"type":"number"},"status":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"handlers.timingRevisionDTO":{"properties":{"author":{"type":"string"},"created_at":{"type":"string"},"id":{"type":"integer"},"reciter":{"type":"string"},"slug":{"type":"string"},"source":{"type":"string"},"timing":{"$ref":"#/components/schemas/models.Timing"},"verse_key":{"type":"string"}},"type":"object"},"handlers.timingRevisionDiffDTO":{"properties":{"from":{"type":"integer"},"segments":{"items":{"$ref":"#/components/schemas/models.SegmentDiff"},"type":"array","uniqueItems":false},"to":{"type":"integer"}},"type":"object"},"handlers.updateRecitationDTO":{"properties":{"auto_lafzize":{"type":"boolean"},"name":{"type":"string"}},"type":"object"},"handlers.updateUserDTO":{"properties":{"displayname":{"type":"string"}},"type":"object"},"models.ChapterTiming":{"properties":{"basmala":{"$ref":"#/components/schemas/models.VerseTiming"},"chapter":{"type":"integer"},"verses":{"items":{"$ref":"#/components/schemas/models.VerseTiming"},"type":"array","uniqueItems":false}},"type":"object"},"models.Error":{"properties":{"error":{"type":"string"},"message":{"type":"string"}},"type":"object"},"models.Segment":{"properties":{"end":{"type":"number"},"score":{"type":"number"},"start":{"type":"number"},"text":{"type":"string"}},"type":"object"},"models.SegmentDiff":{"properties":{"end_delta":{"type":"number"},"index":{"type":"integer"},"new":{"$ref":"#/components/schemas/models.Segment"},"old":{"$ref":"#/components/schemas/models.Segment"},"start_delta":{"type":"number"},"status":{"type":"string"}},"type":"object"},"models.SegmentError":{"properties":{"field":{"type":"string"},"message":{"type":"string"},"segment":{"type":"integer"}},"type":"object"},"models.Timing":{"properties":{"segments":{"items":{"$ref":"#/components/schemas/models.Segment"},"type":"array","uniqueItems":false},"text":{"type":"string"}},"type":"object"},"models.TimingValidationError":{"properties":{"error":{"type":"string"},"errors":{"items":{"$ref":"#/components/schemas/models.SegmentError"},"type":"array","uniqueItems":false},"message":{"type":"string"}},"type":"object"},"models.VerseTiming":{"properties":{"end":{"type":"number"},"segments":{"items":{"$ref":"#/components/schemas/models.Segment"},"type":"array","uniqueItems":false},"start":{"type":"number"},"verse_key":{"type":"string"}},"type":"object"},"sql.NullBool":{"properties":{"bool":{"type":"boolean"},"valid":{"description":"Valid is true if Bool is not NULL","type":"boolean"}},"type":"object"},"sql.NullInt64":{"properties":{"int64":{"format":"int64","type":"integer"},"valid":{"description":"Valid is true if Int64 is not NULL","type":"boolean"}},"type":"object"},"sqlc.AuthInsertUserRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.AuthSelectUserRow":{"properties":{"password":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.LafzizeBatch":{"properties":{"created_at":{"type":"string"},"id":{"type":"integer"},"reciter":{"type":"string"},"slug":{"type":"string"}},"type":"object"},"sqlc.LafzizeJob":{"properties":{"attempts":{"type":"integer"},"batch_id":{"$ref":"#/components/schemas/sql.NullInt64"},"created_at":{"type":"string"},"error":{"type":"string"},"id":{"type":"integer"},"reciter":{"type":"string"},"slug":{"type":"string"},"state":{"type":"string"},"updated_at":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"sqlc.Recitation":{"properties":{"auto_lafzize":{"$ref":"#/components/schemas/sql.NullBool"},"name":{"type":"string"},"reciter":{"type":"string"},"slug":{"type":"string"}},"type":"object"},"sqlc.RecitationFile":{"properties":{"bit_rate":{"type":"integer"},"channels":{"type":"integer"},"duration":{"type":"number"},"has_timings":{"type":"boolean"},"lafzize_error":{"type":"string"},"lafzize_processing":{"type":"boolean"},"reciter":{"type":"string"},"sample_rate":{"type":"integer"},"size":{"type":"integer"},"slug":{"type":"string"},"transcode_error":{"type":"string"},"transcode_status":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"sqlc.Session":{"properties":{"csrf_token":{"type":"string"},"session_token":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.TimingRevisionSelectTimingRevisionsRow":{"properties":{"author":{"type":"string"},"created_at":{"type":"string"},"id":{"type":"integer"},"reciter":{"type":"string"},"slug":{"type":"string"},"source":{"type":"string"},"verse_key":{"type":"string"}},"type":"object"},"sqlc.UserDeleteUserRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.UserSelectUsersRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"},"sqlc.UserUpdateUserRow":{"properties":{"displayname":{"type":"string"},"username":{"type":"string"}},"type":"object"}}},
     "info": {"description":"{{escape .Description}}","title":"{{.Title}}","version":"{{.Version}}"},
     "externalDocs": {"description":"","url":""},
-    "paths": {"/lafzize/{slug}/{verse_key}":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Recitation slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key of recitation","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFileUpdateRecitationFileParams"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["lafzize"]}},"/login":{"post":{"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.loginDTO"}}},"description":"Login","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.AuthSelectUserRow"}}},"description":"OK","headers":{"CSRF\tToken":{"schema":{"type":"string"}},"Session\tToken":{"schema":{"type":"string"}}}},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Auth"]}},"/logout":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Session"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Auth"]}},"/recitation-files/{reciter}/{slug}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.RecitationFile"},"type":"array"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationFile"]}},"/recitation-files/{reciter}/{slug}/{verse_key}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFile"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationFile"]}},"/recitation-files/{slug}/":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"multipart/form-data":{"schema":{"type":"string"}}},"description":"Verse Key","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFile"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationFile"]}},"/recitation-files/{slug}/{verse_key}":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFile"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationFile"]}},"/recitation-timings/{slug}/{verse_key}":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationTiming"]},"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"Update Recitation Timing","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationTiming"]}},"/recitations":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.Recitation"},"type":"array"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]},"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.createRecitationDTO"}}},"description":"Create Recitation","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Recitation"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/recitations/{reciter}/{slug}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Recitation"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/recitations/{slug}":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Recitation"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]},"put":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.updateRecitationDTO"}}},"description":"Update Recitation","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Recitation"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/register":{"post":{"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.registerDTO"}}},"description":"Register","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.AuthInsertUserRow"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Auth"]}},"/user":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.UserDeleteUserRow"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["User"]},"put":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.updateUserDTO"}}},"description":"Update Recitation","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.UserUpdateUserRow"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["User"]}},"/users":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.UserSelectUsersRow"},"type":"array"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["User"]}},"/users/{username}":{"get":{"parameters":[{"description":"Username","in":"path","name":"username","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.UserSelectUsersRow"},"type":"array"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"}},"tags":["User"]}}},
+    "paths": {"/admin/fsck":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/fsck.Report"}}},"description":"OK"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"403":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Forbidden"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Admin"]}},"/admin/fsck/repair":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/fsck.Report"}}},"description":"OK"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"403":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Forbidden"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Admin"]}},"/api/v4/chapter_recitations/{id}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"id","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranChapterAudioFilesDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"}},"tags":["QuranAPI"]}},"/api/v4/chapter_recitations/{id}/{chapter_number}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"id","required":true,"schema":{"type":"integer"}},{"description":"Chapter number","in":"path","name":"chapter_number","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranChapterAudioFileResponseDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"}},"tags":["QuranAPI"]}},"/api/v4/recitations/{recitation_id}/by_ayah/{ayah_key}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"recitation_id","required":true,"schema":{"type":"integer"}},{"description":"Verse key","in":"path","name":"ayah_key","required":true,"schema":{"type":"string"}},{"description":"Page","in":"query","name":"page","schema":{"type":"integer"}},{"description":"Records per page","in":"query","name":"per_page","schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranAudioFilesDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["QuranAPI"]}},"/api/v4/recitations/{recitation_id}/by_chapter/{chapter_number}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"recitation_id","required":true,"schema":{"type":"integer"}},{"description":"Chapter number","in":"path","name":"chapter_number","required":true,"schema":{"type":"integer"}},{"description":"Page","in":"query","name":"page","schema":{"type":"integer"}},{"description":"Records per page","in":"query","name":"per_page","schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranAudioFilesDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["QuranAPI"]}},"/api/v4/recitations/{recitation_id}/by_hizb/{hizb_number}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"recitation_id","required":true,"schema":{"type":"integer"}},{"description":"Hizb number","in":"path","name":"hizb_number","required":true,"schema":{"type":"integer"}},{"description":"Page","in":"query","name":"page","schema":{"type":"integer"}},{"description":"Records per page","in":"query","name":"per_page","schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranAudioFilesDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["QuranAPI"]}},"/api/v4/recitations/{recitation_id}/by_juz/{juz_number}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"recitation_id","required":true,"schema":{"type":"integer"}},{"description":"Juz number","in":"path","name":"juz_number","required":true,"schema":{"type":"integer"}},{"description":"Page","in":"query","name":"page","schema":{"type":"integer"}},{"description":"Records per page","in":"query","name":"per_page","schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranAudioFilesDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["QuranAPI"]}},"/api/v4/recitations/{recitation_id}/by_page/{page_number}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"recitation_id","required":true,"schema":{"type":"integer"}},{"description":"Mushaf page number","in":"path","name":"page_number","required":true,"schema":{"type":"integer"}},{"description":"Page","in":"query","name":"page","schema":{"type":"integer"}},{"description":"Records per page","in":"query","name":"per_page","schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranAudioFilesDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["QuranAPI"]}},"/api/v4/recitations/{recitation_id}/by_rub/{rub_el_hizb_number}":{"get":{"parameters":[{"description":"Recitation ID","in":"path","name":"recitation_id","required":true,"schema":{"type":"integer"}},{"description":"Rub el hizb number","in":"path","name":"rub_el_hizb_number","required":true,"schema":{"type":"integer"}},{"description":"Page","in":"query","name":"page","schema":{"type":"integer"}},{"description":"Records per page","in":"query","name":"per_page","schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranAudioFilesDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["QuranAPI"]}},"/api/v4/resources/recitations":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.quranRecitationsDTO"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["QuranAPI"]}},"/audio/{reciter}/{slug}/{verse_key}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"description":"OK"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"406":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Acceptable"}},"tags":["RecitationFile"]}},"/audio/{reciter}/{slug}/{verse_key}/{format}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}},{"description":"Transcoding profile, or master for the original upload. Negotiated from the Accept header if omitted","in":"path","name":"format","schema":{"type":"string"}}],"responses":{"200":{"description":"OK"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"406":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Acceptable"}},"tags":["RecitationFile"]}},"/chapter-audio/{reciter}/{slug}/{chapter}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Chapter","in":"path","name":"chapter","required":true,"schema":{"type":"integer"}},{"description":"Prepend the basmala (1:1) to chapters other than 1 and 9","in":"query","name":"basmala","schema":{"type":"boolean"}}],"responses":{"200":{"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Chapter"]}},"/chapter-timings/{reciter}/{slug}/{chapter}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Chapter","in":"path","name":"chapter","required":true,"schema":{"type":"integer"}},{"description":"Prepend the basmala (1:1) to chapters other than 1 and 9","in":"query","name":"basmala","schema":{"type":"boolean"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.ChapterTiming"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Chapter"]}},"/events/{reciter}/{slug}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"text/event-stream":{"schema":{"$ref":"#/components/schemas/events.Event"}}},"description":"OK"},"500":{"content":{"text/event-stream":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Events"]}},"/everyayah/{reciter}/{slug}/timings/{file}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"EveryAyah file name, for example 001001.json","in":"path","name":"file","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"OK"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"}},"tags":["EveryAyah"]}},"/everyayah/{reciter}/{slug}/{file}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"EveryAyah file name, for example 001001.mp3","in":"path","name":"file","required":true,"schema":{"type":"string"}}],"responses":{"200":{"description":"OK"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"}},"tags":["EveryAyah"]}},"/everyayah/{slug}/export":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Archive format","in":"query","name":"format","schema":{"default":"zip","enum":["zip","tar","tar.gz"],"type":"string"}}],"responses":{"200":{"description":"OK"},"400":{"content":{"application/gzip":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/x-tar":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/zip":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/gzip":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/x-tar":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/zip":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/gzip":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/x-tar":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/zip":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["EveryAyah"]}},"/lafzize-batches/{id}":{"get":{"parameters":[{"description":"Batch ID","in":"path","name":"id","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.lafzizeBatchDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["lafzize"]}},"/lafzize/{reciter}/{slug}/{verse_key}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Recitation slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key of recitation","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.LafzizeJob"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"}},"tags":["lafzize"]}},"/lafzize/{slug}":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Recitation slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.lafzizeRecitationDTO"}}},"description":"Filters"},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.lafzizeBatchDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["lafzize"]}},"/lafzize/{slug}/{verse_key}":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Recitation slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key of recitation","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.LafzizeJob"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["lafzize"]},"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Recitation slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key of recitation","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.LafzizeJob"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["lafzize"]}},"/login":{"post":{"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.loginDTO"}}},"description":"Login","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.AuthSelectUserRow"}}},"description":"OK","headers":{"CSRF\tToken":{"schema":{"type":"string"}},"Session\tToken":{"schema":{"type":"string"}}}},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Auth"]}},"/logout":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Session"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Auth"]}},"/recitation-files/{reciter}/{slug}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.RecitationFile"},"type":"array"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationFile"]}},"/recitation-files/{reciter}/{slug}/{verse_key}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFile"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationFile"]}},"/recitation-files/{slug}/":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"multipart/form-data":{"schema":{"type":"string"}}},"description":"Verse Key","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFile"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationFile"]}},"/recitation-files/{slug}/chapter":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"multipart/form-data":{"schema":{"type":"string"}}},"description":"JSON word level alignment of the chapter, in the format of recitation timings. The chapter is aligned with the configured aligner if neither boundaries nor alignment is given"},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.splitSummaryDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationFile"]}},"/recitation-files/{slug}/{verse_key}":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFile"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationFile"]}},"/recitation-files/{slug}/{verse_key}/transcode":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.RecitationFile"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationFile"]}},"/recitation-timings/{reciter}/{slug}/{verse_key}/revisions":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.TimingRevisionSelectTimingRevisionsRow"},"type":"array"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationTiming"]}},"/recitation-timings/{reciter}/{slug}/{verse_key}/revisions/{id}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}},{"description":"Revision ID","in":"path","name":"id","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.timingRevisionDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"}},"tags":["RecitationTiming"]}},"/recitation-timings/{reciter}/{slug}/{verse_key}/revisions/{id}/diff/{other_id}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}},{"description":"Revision ID to diff from","in":"path","name":"id","required":true,"schema":{"type":"integer"}},{"description":"Revision ID to diff to","in":"path","name":"other_id","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.timingRevisionDiffDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"}},"tags":["RecitationTiming"]}},"/recitation-timings/{slug}/{verse_key}":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationTiming"]},"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"Update Recitation Timing","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.TimingValidationError"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationTiming"]}},"/recitation-timings/{slug}/{verse_key}/restore":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Timing"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["RecitationTiming"]}},"/recitation-timings/{slug}/{verse_key}/revisions/{id}/restore":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Verse Key","in":"path","name":"verse_key","required":true,"schema":{"type":"string"}},{"description":"Revision ID","in":"path","name":"id","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.timingRevisionDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["RecitationTiming"]}},"/recitations":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.Recitation"},"type":"array"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]},"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.createRecitationDTO"}}},"description":"Create Recitation","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Recitation"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/recitations/{reciter}/{slug}":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.recitationDTO"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/recitations/{reciter}/{slug}/archive":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"description":"Archive format","in":"query","name":"format","schema":{"default":"zip","enum":["zip","tar","tar.gz"],"type":"string"}},{"description":"Only include verses of this chapter","in":"query","name":"chapter","schema":{"type":"integer"}}],"responses":{"200":{"description":"OK"},"400":{"content":{"application/gzip":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/x-tar":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/zip":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"500":{"content":{"application/gzip":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/x-tar":{"schema":{"$ref":"#/components/schemas/models.Error"}},"application/zip":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/recitations/{reciter}/{slug}/coverage":{"get":{"parameters":[{"description":"Reciter","in":"path","name":"reciter","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.coverageDTO"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/recitations/{slug}":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Recitation"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]},"put":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.updateRecitationDTO"}}},"description":"Update Recitation","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.Recitation"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/recitations/{slug}/import":{"post":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}},{"description":"Slug","in":"path","name":"slug","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"multipart/form-data":{"schema":{"enum":["zip","tar","tar.gz"],"type":"string"}}},"description":"Archive format, detected from the file name by default"},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.importSummaryDTO"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Recitation"]}},"/register":{"post":{"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.registerDTO"}}},"description":"Register","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.AuthInsertUserRow"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["Auth"]}},"/uploads/{path}":{"get":{"parameters":[{"description":"Path of the file, such as {reciter}/{slug}/{verse_key}.mp3","in":"path","name":"path","required":true,"schema":{"type":"string"}}],"responses":{"200":{"description":"OK"},"302":{"description":"Found"},"404":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}},"audio/mpeg":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Not Found"}},"tags":["RecitationFile"]}},"/user":{"delete":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.UserDeleteUserRow"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["User"]},"put":{"parameters":[{"description":"CSRF Token","in":"header","name":"X-CSRF-TOKEN","required":true,"schema":{"type":"string"}}],"requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/handlers.updateUserDTO"}}},"description":"Update Recitation","required":true},"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/sqlc.UserUpdateUserRow"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"},"401":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Unauthorized"}},"tags":["User"]}},"/users":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.UserSelectUsersRow"},"type":"array"}}},"description":"OK"},"500":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Internal Server Error"}},"tags":["User"]}},"/users/{username}":{"get":{"parameters":[{"description":"Username","in":"path","name":"username","required":true,"schema":{"type":"string"}}],"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/sqlc.UserSelectUsersRow"},"type":"array"}}},"description":"OK"},"400":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/models.Error"}}},"description":"Bad Request"}},"tags":["User"]}}},
     "openapi": "3.1.0"
 }`
 
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/blobs"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/fsck"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+)
+
+// runFsck implements `tilawah-hub fsck [-repair]`, which reports
+// inconsistencies between the database and the uploads, one per line, and
+// exits with status 1 if any are left unrepaired.
+func runFsck(args []string) {
+	flags := flag.NewFlagSet("fsck", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "repair the inconsistencies found")
+	flags.Parse(args)
+
+	err := storage.Initialise()
+	if err != nil {
+		log.Fatalf("Error initialising storage: %v", err)
+	}
+	storage.Resolve = blobs.Resolve
+
+	report, err := fsck.Check(context.Background(), *repair)
+	if err != nil {
+		log.Fatalf("Error checking storage consistency: %v", err)
+	}
+
+	for _, issue := range report.Issues {
+		status := ""
+		switch {
+		case issue.Repaired && issue.Error != "":
+			status = " (" + issue.Error + ")"
+		case issue.Repaired:
+			status = " (repaired)"
+		case issue.Error != "":
+			status = " (error repairing: " + issue.Error + ")"
+		}
+		fmt.Printf("%s\t%s\t%s%s\n", issue.Kind, issue.Path, issue.Detail, status)
+	}
+	fmt.Printf("%d issues found, %d repaired\n", len(report.Issues), report.Repaired)
+
+	if len(report.Issues) > report.Repaired {
+		os.Exit(1)
+	}
+}
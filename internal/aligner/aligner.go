@@ -0,0 +1,75 @@
+package aligner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/spf13/viper"
+)
+
+// Aligner produces word level timings for the audio of a verse. The verse key
+// may also be a range of verses of a chapter formatted by quran.VerseRange,
+// such as 1:1-7, when a recording of several verses is aligned.
+type Aligner interface {
+	Align(ctx context.Context, audioPath string, verseKey string) (models.Timing, error)
+}
+
+// TemporaryError marks an alignment failure that may succeed if retried.
+type TemporaryError struct {
+	Err error
+}
+
+func (e *TemporaryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TemporaryError) Unwrap() error {
+	return e.Err
+}
+
+// IsTemporary reports whether err is worth retrying.
+func IsTemporary(err error) bool {
+	var temporary *TemporaryError
+	return errors.As(err, &temporary)
+}
+
+// New returns the aligner selected by the `aligner` config key.
+func New() (Aligner, error) {
+	switch viper.GetString("aligner") {
+	case "lafzize":
+		return &Lafzize{
+			Endpoint: viper.GetString("lafzize_endpoint"),
+			Client:   &http.Client{},
+		}, nil
+	case "command":
+		argv := viper.GetStringSlice("aligner_command")
+		if len(argv) == 0 {
+			return nil, errors.New("aligner_command must be set when using the command aligner")
+		}
+		return &Command{Argv: argv}, nil
+	case "fake":
+		return &Fake{
+			Duration: audio.Duration,
+			Words: func(verseKey string) []string {
+				chapter, from, to, err := quran.ParseVerseRange(verseKey)
+				if err != nil {
+					return nil
+				}
+
+				words := []string{}
+				for verse := from; verse <= to; verse++ {
+					verseWords, _ := quran.Words(quran.VerseKey(chapter, verse))
+					words = append(words, verseWords...)
+				}
+				return words
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown aligner %q", viper.GetString("aligner"))
+	}
+}
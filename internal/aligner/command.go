@@ -0,0 +1,46 @@
+package aligner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+)
+
+// Command aligns verses by running a local program. Occurrences of
+// `{audio}` and `{verse_key}` in Argv are substituted, and the program must
+// print the timing JSON to stdout.
+type Command struct {
+	Argv []string
+}
+
+func (c *Command) Align(ctx context.Context, audioPath string, verseKey string) (models.Timing, error) {
+	var timing models.Timing
+
+	replacer := strings.NewReplacer("{audio}", audioPath, "{verse_key}", verseKey)
+	argv := make([]string, len(c.Argv))
+	for i, arg := range c.Argv {
+		argv[i] = replacer.Replace(arg)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return timing, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	err = json.Unmarshal(stdout.Bytes(), &timing)
+	if err != nil {
+		return timing, fmt.Errorf("error decoding aligner output: %w", err)
+	}
+
+	return timing, nil
+}
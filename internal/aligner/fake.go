@@ -0,0 +1,45 @@
+package aligner
+
+import (
+	"context"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+)
+
+// Fake is an in-process aligner for tests which splits the audio into evenly
+// spaced segments, one per word.
+type Fake struct {
+	// Duration returns the duration of the audio in seconds, such as
+	// audio.Duration.
+	Duration func(audioPath string) (float64, error)
+	// Words returns the words of a verse. A single segment spanning the
+	// whole audio is produced when it is nil or returns no words.
+	Words func(verseKey string) []string
+}
+
+func (f *Fake) Align(ctx context.Context, audioPath string, verseKey string) (models.Timing, error) {
+	duration, err := f.Duration(audioPath)
+	if err != nil {
+		return models.Timing{}, err
+	}
+
+	words := []string{""}
+	if f.Words != nil {
+		if verseWords := f.Words(verseKey); len(verseWords) > 0 {
+			words = verseWords
+		}
+	}
+
+	timing := models.Timing{Segments: []models.Segment{}}
+
+	length := duration / float64(len(words))
+	for i, word := range words {
+		timing.Segments = append(timing.Segments, models.Segment{
+			Start: float64(i) * length,
+			End:   float64(i+1) * length,
+			Text:  word,
+		})
+	}
+
+	return timing, nil
+}
@@ -0,0 +1,82 @@
+package aligner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+)
+
+// Lafzize aligns verses by posting them to a lafzize server.
+type Lafzize struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+func (l *Lafzize) Align(ctx context.Context, audioPath string, verseKey string) (models.Timing, error) {
+	var timing models.Timing
+
+	file, err := os.Open(audioPath)
+	if err != nil {
+		return timing, err
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", filepath.Base(file.Name()))
+	if err != nil {
+		return timing, err
+	}
+
+	_, err = io.Copy(part, file)
+	if err != nil {
+		return timing, err
+	}
+
+	err = writer.WriteField("verse_key", verseKey)
+	if err != nil {
+		return timing, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return timing, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", l.Endpoint, body)
+	if err != nil {
+		return timing, err
+	}
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+
+	resp, err := l.Client.Do(request)
+	if err != nil {
+		return timing, &TemporaryError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err = fmt.Errorf("lafzize server responded with %s: %s", resp.Status, bytes.TrimSpace(message))
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			return timing, &TemporaryError{Err: err}
+		}
+		return timing, err
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&timing)
+	if err != nil {
+		return timing, fmt.Errorf("error decoding lafzize response: %w", err)
+	}
+
+	return timing, nil
+}
@@ -0,0 +1,153 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// DetectFormat returns the archive format of a file from its name.
+func DetectFormat(name string) (string, error) {
+	name = strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar, nil
+	default:
+		return "", fmt.Errorf("unknown archive format of %q", name)
+	}
+}
+
+// ErrTooLarge is returned when an archive exceeds its Limits.
+var ErrTooLarge = errors.New("archive is too large")
+
+// Limits bound the decompressed size of an archive.
+type Limits struct {
+	// Entry is the largest size of a single file.
+	Entry int64
+	// Total is the largest size of all files together.
+	Total int64
+}
+
+// Check reads the declared sizes of the files in an archive, failing with
+// ErrTooLarge if they exceed the limits, so that such an archive can be
+// rejected before any of it is used.
+func Check(r io.ReaderAt, size int64, format string, limits Limits) error {
+	var total int64
+	return walk(r, size, format, func(name string, size int64, content io.Reader) error {
+		if size > limits.Entry {
+			return fmt.Errorf("%w: %s is larger than %d bytes", ErrTooLarge, name, limits.Entry)
+		}
+		total += size
+		if total > limits.Total {
+			return fmt.Errorf("%w: its files are larger than %d bytes in total", ErrTooLarge, limits.Total)
+		}
+		return nil
+	}, false)
+}
+
+// Walk calls fn with the name and content of every regular file in an
+// archive, in the order they are stored. The content of each file is cut off
+// at the entry limit, and reading past the total limit fails with
+// ErrTooLarge.
+func Walk(r io.ReaderAt, size int64, format string, limits Limits, fn func(name string, content io.Reader) error) error {
+	remaining := limits.Total
+	return walk(r, size, format, func(name string, size int64, content io.Reader) error {
+		if size > min(limits.Entry, remaining) {
+			return fmt.Errorf("%w: %s does not fit the limits", ErrTooLarge, name)
+		}
+		remaining -= size
+		return fn(name, io.LimitReader(content, size))
+	}, true)
+}
+
+// walk calls fn with the declared size of every regular file in an archive,
+// and its content if open is set.
+func walk(r io.ReaderAt, size int64, format string, fn func(name string, size int64, content io.Reader) error, open bool) error {
+	switch format {
+	case FormatZip:
+		return walkZip(r, size, fn, open)
+	case FormatTar:
+		return walkTar(io.NewSectionReader(r, 0, size), fn)
+	case FormatTarGz:
+		gzipReader, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+
+		return walkTar(gzipReader, fn)
+	default:
+		return fmt.Errorf("unknown archive format %q", format)
+	}
+}
+
+func walkZip(r io.ReaderAt, size int64, fn func(name string, size int64, content io.Reader) error, open bool) error {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range zipReader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		// Reading more than the uncompressed size fails, so it can be trusted.
+		fileSize := int64(min(file.UncompressedSize64, math.MaxInt64))
+		if !open {
+			err = fn(file.Name, fileSize, nil)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+
+		err = fn(file.Name, fileSize, content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkTar passes the content of files to fn whether or not it is needed, as
+// skipping it reads it all the same.
+func walkTar(r io.Reader, fn func(name string, size int64, content io.Reader) error) error {
+	tarReader := tar.NewReader(r)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		err = fn(header.Name, header.Size, tarReader)
+		if err != nil {
+			return err
+		}
+	}
+}
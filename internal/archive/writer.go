@@ -0,0 +1,113 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+const (
+	FormatZip   = "zip"
+	FormatTar   = "tar"
+	FormatTarGz = "tar.gz"
+)
+
+// Writer streams files into an archive.
+type Writer interface {
+	// Add writes a file of the given size to the archive.
+	Add(name string, size int64, modTime time.Time, content io.Reader) error
+	// Close finishes the archive without closing the underlying writer.
+	Close() error
+}
+
+// NewWriter returns a Writer for an archive format.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatZip:
+		return &zipWriter{writer: zip.NewWriter(w)}, nil
+	case FormatTar:
+		return &tarWriter{writer: tar.NewWriter(w)}, nil
+	case FormatTarGz:
+		gzipWriter := gzip.NewWriter(w)
+		return &tarWriter{writer: tar.NewWriter(gzipWriter), gzip: gzipWriter}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive format %q", format)
+	}
+}
+
+// ContentType returns the MIME type of an archive format.
+func ContentType(format string) string {
+	switch format {
+	case FormatZip:
+		return "application/zip"
+	case FormatTarGz:
+		return "application/gzip"
+	default:
+		return "application/x-tar"
+	}
+}
+
+type zipWriter struct {
+	writer *zip.Writer
+}
+
+func (z *zipWriter) Add(name string, size int64, modTime time.Time, content io.Reader) error {
+	header := &zip.FileHeader{
+		Name:     name,
+		Modified: modTime,
+		Method:   zip.Deflate,
+	}
+	// Audio is already compressed
+	if path.Ext(name) != ".json" {
+		header.Method = zip.Store
+	}
+
+	fileWriter, err := z.writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fileWriter, content)
+	return err
+}
+
+func (z *zipWriter) Close() error {
+	return z.writer.Close()
+}
+
+type tarWriter struct {
+	writer *tar.Writer
+	gzip   *gzip.Writer
+}
+
+func (t *tarWriter) Add(name string, size int64, modTime time.Time, content io.Reader) error {
+	err := t.writer.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(t.writer, content, size)
+	return err
+}
+
+func (t *tarWriter) Close() error {
+	err := t.writer.Close()
+	if err != nil {
+		return err
+	}
+
+	if t.gzip != nil {
+		return t.gzip.Close()
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// Duration returns the duration of an audio file in seconds, as reported by
+// ffprobe.
+func Duration(path string) (float64, error) {
+	metadata, err := Probe(path)
+	if err != nil {
+		return 0, err
+	}
+	return metadata.Duration, nil
+}
+
+// Metadata describes an audio file.
+type Metadata struct {
+	Duration   float64
+	BitRate    int64
+	SampleRate int64
+	Channels   int64
+	Size       int64
+}
+
+// Probe returns the metadata of an audio file, as reported by ffprobe, taking
+// the sample rate and channels from its first audio stream.
+func Probe(path string) (Metadata, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-select_streams", "a:0", "-show_entries", "format=duration,bit_rate,size:stream=sample_rate,channels", "-of", "json", path)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+			Size     string `json:"size"`
+		} `json:"format"`
+		Streams []struct {
+			SampleRate string `json:"sample_rate"`
+			Channels   int64  `json:"channels"`
+		} `json:"streams"`
+	}
+	err = json.Unmarshal(output, &probe)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if len(probe.Streams) == 0 {
+		return Metadata{}, errors.New("no audio stream found")
+	}
+
+	var metadata Metadata
+	metadata.Channels = probe.Streams[0].Channels
+
+	metadata.Duration, err = strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("invalid duration: %w", err)
+	}
+	metadata.BitRate, err = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("invalid bit rate: %w", err)
+	}
+	metadata.SampleRate, err = strconv.ParseInt(probe.Streams[0].SampleRate, 10, 64)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("invalid sample rate: %w", err)
+	}
+	metadata.Size, err = strconv.ParseInt(probe.Format.Size, 10, 64)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("invalid size: %w", err)
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,243 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// MasterFormat is the format under which the original upload is kept when
+// keep_master is enabled.
+const MasterFormat = "master"
+
+// primaryExtension is the extension of the profile the timings, chapters and
+// exports are based on.
+const primaryExtension = "mp3"
+
+// Profile is an output format every upload is transcoded to.
+type Profile struct {
+	Name        string   `mapstructure:"name"`
+	Extension   string   `mapstructure:"extension"`
+	ContentType string   `mapstructure:"content_type"`
+	Args        []string `mapstructure:"args"`
+}
+
+var profiles []Profile
+
+// LoadProfiles reads the transcoding profiles from the config. Exactly one
+// profile must produce mp3, as the rest of tilawah-hub works with it.
+func LoadProfiles() error {
+	var loaded []Profile
+	err := viper.UnmarshalKey("transcoding_profiles", &loaded)
+	if err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	extensions := map[string]bool{}
+	for _, profile := range loaded {
+		switch {
+		case profile.Name == "" || profile.Extension == "" || profile.ContentType == "":
+			return fmt.Errorf("profile %q needs a name, extension and content_type", profile.Name)
+		case profile.Name == MasterFormat:
+			return fmt.Errorf("profile name %q is reserved", MasterFormat)
+		case profile.Extension == "json" || profile.Extension == "raw" || profile.Extension == MasterFormat:
+			return fmt.Errorf("profile extension %q is reserved", profile.Extension)
+		case names[profile.Name]:
+			return fmt.Errorf("duplicate profile name %q", profile.Name)
+		case extensions[profile.Extension]:
+			return fmt.Errorf("duplicate profile extension %q", profile.Extension)
+		}
+		names[profile.Name] = true
+		extensions[profile.Extension] = true
+	}
+
+	if !extensions[primaryExtension] {
+		return fmt.Errorf("a profile with the extension %q is required", primaryExtension)
+	}
+
+	profiles = loaded
+	return nil
+}
+
+// Profiles returns the configured transcoding profiles.
+func Profiles() []Profile {
+	return profiles
+}
+
+// LookupProfile returns the profile with the given name.
+func LookupProfile(name string) (Profile, bool) {
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
+
+// Path returns the path of the audio of a verse in the given profile.
+func (profile Profile) Path(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, verseKey+"."+profile.Extension)
+}
+
+// PrimaryPath returns the path of the mp3 audio of a verse.
+func PrimaryPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, verseKey+"."+primaryExtension)
+}
+
+// MasterPath returns the path of the original upload of a verse.
+func MasterPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, verseKey+"."+MasterFormat)
+}
+
+// KeepMaster reports whether original uploads are kept.
+func KeepMaster() bool {
+	return viper.GetBool("keep_master")
+}
+
+// Transcode transcodes the input to every profile of a verse, passing
+// options, such as -ss and -to, to ffmpeg before those of the profile. Each output is
+// written to a temporary file first, so that a failure leaves existing audio
+// untouched. ffmpeg's error output is included in the returned error.
+func Transcode(ctx context.Context, input string, options []string, reciter string, slug string, verseKey string) error {
+	temporaryFilepaths := map[string]string{}
+	defer func() {
+		for _, temporaryFilepath := range temporaryFilepaths {
+			os.Remove(temporaryFilepath)
+		}
+	}()
+
+	for _, profile := range profiles {
+		outputFilepath := profile.Path(reciter, slug, verseKey)
+		temporaryFilepath := outputFilepath + ".tmp." + profile.Extension
+		temporaryFilepaths[outputFilepath] = temporaryFilepath
+
+		args := append([]string{"-y", "-v", "error", "-i", input}, options...)
+		args = append(args, profile.Args...)
+		args = append(args, temporaryFilepath)
+
+		output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error transcoding to %s: %w: %s", profile.Name, err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	for outputFilepath, temporaryFilepath := range temporaryFilepaths {
+		err := os.Rename(temporaryFilepath, outputFilepath)
+		if err != nil {
+			return err
+		}
+		delete(temporaryFilepaths, outputFilepath)
+	}
+
+	return nil
+}
+
+// Cut writes the part of the input selected by options, such as -ss and -to,
+// to the output as FLAC, so that it can be transcoded again without loss.
+// ffmpeg's error output is included in the returned error.
+func Cut(ctx context.Context, input string, options []string, output string) error {
+	args := append([]string{"-y", "-v", "error", "-i", input}, options...)
+	args = append(args, "-c:a", "flac", "-f", "flac", output)
+
+	combinedOutput, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(combinedOutput)))
+	}
+	return nil
+}
+
+// Paths returns the paths of the audio of a verse in every profile and of
+// its master.
+func Paths(reciter string, slug string, verseKey string) []string {
+	paths := []string{MasterPath(reciter, slug, verseKey)}
+	for _, profile := range profiles {
+		paths = append(paths, profile.Path(reciter, slug, verseKey))
+	}
+	return paths
+}
+
+// Negotiate picks the profile preferred by an Accept header out of those
+// available, falling back to the first available profile in the config
+// order when the header is empty. It reports false if none is acceptable.
+func Negotiate(accept string, available []Profile) (Profile, bool) {
+	if len(available) == 0 {
+		return Profile{}, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return available[0], true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	ranges := []mediaRange{}
+	refused := map[string]bool{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			refused[mediaType] = true
+			continue
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+
+	// More specific ranges take precedence over wildcards of equal quality.
+	specificity := func(mediaType string) int {
+		switch {
+		case mediaType == "*/*":
+			return 0
+		case strings.HasSuffix(mediaType, "/*"):
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+
+	for _, mediaRange := range ranges {
+		for _, profile := range available {
+			if !refused[profile.ContentType] && matchesMediaRange(profile.ContentType, mediaRange.mediaType) {
+				return profile, true
+			}
+		}
+	}
+
+	return Profile{}, false
+}
+
+func matchesMediaRange(contentType string, mediaRange string) bool {
+	if mediaRange == "*/*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(contentType, prefix+"/")
+	}
+	return contentType == mediaRange
+}
@@ -0,0 +1,264 @@
+package blobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// Transcoded audio is stored once per content hash in data/blobs. The audio of
+// a verse at data/uploads/{reciter}/{slug}/{verse_key}.{extension} is a hard
+// link to its blob, so that it keeps working as a logical path, and
+// recitation_file_blobs references the blob. The reference count of a blob is
+// maintained by triggers, and blobs no longer referenced are collected
+// periodically.
+
+// mutex serialises adopting and collecting, so that a blob is not collected
+// while it is being referenced again.
+var mutex sync.Mutex
+
+// Start adopts audio transcoded before blobs were introduced and starts
+// collecting unreferenced blobs.
+func Start() {
+	storage.Resolve = Resolve
+
+	go func() {
+		recitationFiles, err := db.Queries.RecitationFileSelectRecitationFilesWithoutBlobs(context.Background())
+		if err != nil {
+			log.Printf("Error selecting recitation files without blobs: %v\n", err)
+		}
+		for _, recitationFile := range recitationFiles {
+			err = Adopt(context.Background(), recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey)
+			if err != nil {
+				log.Printf("Error adopting audio of %s/%s/%s: %v\n", recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey, err)
+			}
+		}
+
+		for {
+			_, err := Collect(context.Background())
+			if err != nil {
+				log.Printf("Error collecting unreferenced blobs: %v\n", err)
+			}
+			time.Sleep(max(viper.GetDuration("blob_gc_interval"), time.Minute))
+		}
+	}()
+}
+
+// Path returns the path of the blob with the given hash and extension.
+func Path(hash string, extension string) string {
+	return filepath.Join("data", "blobs", hash[:2], hash+"."+extension)
+}
+
+// Adopt moves the audio of a verse in every profile into blobs, replacing it
+// with links to them and referencing them from its recitation file, and stores
+// it. Audio that is identical to an existing blob is deduplicated.
+func Adopt(ctx context.Context, reciter string, slug string, verseKey string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// The audio is fetched before the transaction, as fetching it resolves
+	// its current blob.
+	cachedFilepaths := map[string]string{}
+	for _, profile := range audio.Profiles() {
+		cachedFilepath, err := storage.Fetch(ctx, profile.Path(reciter, slug, verseKey))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		cachedFilepaths[profile.Name] = cachedFilepath
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := db.Queries.WithTx(tx)
+
+	adopted := []string{}
+	for _, profile := range audio.Profiles() {
+		audioFilepath := profile.Path(reciter, slug, verseKey)
+		cachedFilepath, ok := cachedFilepaths[profile.Name]
+		if !ok {
+			continue
+		}
+
+		hash, size, err := hashFile(cachedFilepath)
+		if err != nil {
+			return err
+		}
+		blobFilepath := Path(hash, profile.Extension)
+
+		// Audio that is only cached as its blob is already linked to it.
+		if filepath.Clean(cachedFilepath) == filepath.Clean(audioFilepath) {
+			err = link(audioFilepath, blobFilepath)
+			if err != nil {
+				return err
+			}
+			adopted = append(adopted, audioFilepath)
+		}
+
+		err = storage.Put(ctx, blobFilepath)
+		if err != nil {
+			return fmt.Errorf("error storing blob %s: %w", blobFilepath, err)
+		}
+
+		err = queries.BlobCreateBlob(ctx, sqlc.BlobCreateBlobParams{
+			Hash:      hash,
+			Extension: profile.Extension,
+			Size:      size,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = queries.RecitationFileBlobUpsertRecitationFileBlob(ctx, sqlc.RecitationFileBlobUpsertRecitationFileBlobParams{
+			Reciter:   reciter,
+			Slug:      slug,
+			VerseKey:  verseKey,
+			Extension: profile.Extension,
+			Hash:      hash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	// The audio is now stored as its blob, which replaces any copy stored
+	// before.
+	for _, audioFilepath := range adopted {
+		err = storage.Put(ctx, audioFilepath)
+		if err != nil {
+			log.Printf("Error storing %s: %v\n", audioFilepath, err)
+		}
+	}
+	return nil
+}
+
+// Collect deletes blobs that are no longer referenced, returning their number.
+func Collect(ctx context.Context) (int, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	blobs, err := db.Queries.BlobSelectUnreferencedBlobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	errs := []error{}
+	for _, blob := range blobs {
+		err = storage.Remove(ctx, Path(blob.Hash, blob.Extension))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		_, err = db.Queries.BlobDeleteUnreferencedBlob(ctx, sqlc.BlobDeleteUnreferencedBlobParams{
+			Hash:      blob.Hash,
+			Extension: blob.Extension,
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// Resolve returns the blob that the audio of a verse at the given path of the
+// uploads links to.
+func Resolve(audioFilepath string) (string, bool) {
+	relative, err := filepath.Rel(filepath.Join("data", "uploads"), audioFilepath)
+	if err != nil {
+		return "", false
+	}
+
+	parts := strings.Split(filepath.ToSlash(relative), "/")
+	if len(parts) != 3 {
+		return "", false
+	}
+	verseKey, extension, found := strings.Cut(parts[2], ".")
+	if !found {
+		return "", false
+	}
+
+	hash, err := db.Queries.RecitationFileBlobSelectHash(context.Background(), sqlc.RecitationFileBlobSelectHashParams{
+		Reciter:   parts[0],
+		Slug:      parts[1],
+		VerseKey:  verseKey,
+		Extension: extension,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Error resolving blob of %s: %v\n", audioFilepath, err)
+		return "", false
+	}
+
+	return Path(hash, extension), true
+}
+
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// link makes the audio at the given path a link to the blob, creating the blob
+// out of it if it does not exist yet.
+func link(audioFilepath string, blobFilepath string) error {
+	err := os.MkdirAll(filepath.Dir(blobFilepath), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = os.Link(audioFilepath, blobFilepath)
+	if err == nil || !errors.Is(err, os.ErrExist) {
+		return err
+	}
+
+	// The links are swapped in atomically, so that the audio is never missing.
+	temporaryFilepath := audioFilepath + ".tmp.link"
+	os.Remove(temporaryFilepath)
+	err = os.Link(blobFilepath, temporaryFilepath)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporaryFilepath, audioFilepath)
+}
@@ -0,0 +1,384 @@
+package chapters
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+)
+
+// ErrIncomplete is returned when a chapter is missing the audio of some of
+// its verses.
+var ErrIncomplete = errors.New("chapter is incomplete")
+
+// ErrNotGenerated is returned by Lookup when a chapter has not been generated
+// from the current verse files yet.
+var ErrNotGenerated = errors.New("chapter is not generated yet")
+
+// mutex guards locks, which holds a lock per generated chapter, so that
+// concurrent requests for the same chapter do not generate it twice while
+// other chapters are generated in parallel.
+var mutex sync.Mutex
+var locks = map[string]*sync.Mutex{}
+
+// generating bounds the number of chapters generated at once, whether on
+// request or in the background, and queued the chapters waiting to be
+// generated in the background.
+var generating = make(chan struct{}, 1)
+var queued = map[string]bool{}
+
+// basmalaVerseKey is the verse whose audio is used as the basmala of other
+// chapters.
+const basmalaVerseKey = "1:1"
+
+// AudioPath returns the path of the concatenated audio of a chapter.
+func AudioPath(reciter string, slug string, chapter int, basmala bool) string {
+	return filepath.Join(dir(reciter, slug), name(chapter, basmala)+".mp3")
+}
+
+// TimingsPath returns the path of the timings of a chapter.
+func TimingsPath(reciter string, slug string, chapter int, basmala bool) string {
+	return filepath.Join(dir(reciter, slug), name(chapter, basmala)+".json")
+}
+
+func fingerprintPath(reciter string, slug string, chapter int, basmala bool) string {
+	return filepath.Join(dir(reciter, slug), name(chapter, basmala)+".fingerprint")
+}
+
+func dir(reciter string, slug string) string {
+	return filepath.Join("data", "uploads", reciter, slug, "chapters")
+}
+
+func name(chapter int, basmala bool) string {
+	if basmala {
+		return strconv.Itoa(chapter) + "-basmala"
+	}
+	return strconv.Itoa(chapter)
+}
+
+// Ensure generates the audio and timings of a chapter by concatenating the
+// audio of its verses, unless they were already generated from the current
+// verse files, and returns their paths. With basmala, the audio of 1:1 is
+// prepended to chapters other than 1 and 9. Chapters are generated one at a
+// time, so it may wait for others to be generated first.
+func Ensure(reciter string, slug string, chapter int, basmala bool) (string, string, error) {
+	if quran.VerseCount(chapter) == 0 {
+		return "", "", fmt.Errorf("there is no chapter %d", chapter)
+	}
+	basmala = basmala && chapter != 1 && chapter != 9
+
+	unlock := lock(reciter, slug, chapter, basmala)
+	defer unlock()
+
+	verseKeys, fingerprint, err := lookup(reciter, slug, chapter, basmala)
+	if err == nil {
+		return AudioPath(reciter, slug, chapter, basmala), TimingsPath(reciter, slug, chapter, basmala), nil
+	}
+	if !errors.Is(err, ErrNotGenerated) {
+		return "", "", err
+	}
+
+	generating <- struct{}{}
+	err = generate(reciter, slug, chapter, basmala, verseKeys)
+	<-generating
+	if err != nil {
+		return "", "", err
+	}
+
+	err = storage.WriteFile(context.Background(), fingerprintPath(reciter, slug, chapter, basmala), []byte(fingerprint))
+	if err != nil {
+		return "", "", err
+	}
+	return AudioPath(reciter, slug, chapter, basmala), TimingsPath(reciter, slug, chapter, basmala), nil
+}
+
+// Lookup returns the paths of the audio and timings of a chapter if they were
+// generated from the current verse files, without generating them.
+func Lookup(reciter string, slug string, chapter int, basmala bool) (string, string, error) {
+	if quran.VerseCount(chapter) == 0 {
+		return "", "", fmt.Errorf("there is no chapter %d", chapter)
+	}
+	basmala = basmala && chapter != 1 && chapter != 9
+
+	_, _, err := lookup(reciter, slug, chapter, basmala)
+	if err != nil {
+		return "", "", err
+	}
+	return AudioPath(reciter, slug, chapter, basmala), TimingsPath(reciter, slug, chapter, basmala), nil
+}
+
+// EnsureLater queues a chapter to be generated in the background, unless it
+// already is.
+func EnsureLater(reciter string, slug string, chapter int, basmala bool) {
+	basmala = basmala && chapter != 1 && chapter != 9
+	key := filepath.Join(dir(reciter, slug), name(chapter, basmala))
+
+	mutex.Lock()
+	if queued[key] {
+		mutex.Unlock()
+		return
+	}
+	queued[key] = true
+	mutex.Unlock()
+
+	go func() {
+		_, _, err := Ensure(reciter, slug, chapter, basmala)
+
+		mutex.Lock()
+		delete(queued, key)
+		mutex.Unlock()
+
+		if err != nil {
+			log.Printf("Error generating chapter %d of %s/%s: %v\n", chapter, reciter, slug, err)
+		}
+	}()
+}
+
+// lock locks a chapter, returning the function that unlocks it.
+func lock(reciter string, slug string, chapter int, basmala bool) func() {
+	key := filepath.Join(dir(reciter, slug), name(chapter, basmala))
+
+	mutex.Lock()
+	chapterMutex, ok := locks[key]
+	if !ok {
+		chapterMutex = &sync.Mutex{}
+		locks[key] = chapterMutex
+	}
+	mutex.Unlock()
+
+	chapterMutex.Lock()
+	return chapterMutex.Unlock
+}
+
+// lookup returns the constituent verses of a chapter and their fingerprint,
+// failing with ErrNotGenerated if the chapter was not generated from them.
+func lookup(reciter string, slug string, chapter int, basmala bool) ([]string, string, error) {
+	verseKeys, err := constituents(reciter, slug, chapter, basmala)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fingerprint, err := computeFingerprint(reciter, slug, verseKeys)
+	if err != nil {
+		return nil, "", err
+	}
+
+	existingFingerprint, err := storage.ReadFile(context.Background(), fingerprintPath(reciter, slug, chapter, basmala))
+	if err != nil || string(existingFingerprint) != fingerprint {
+		return verseKeys, fingerprint, ErrNotGenerated
+	}
+
+	if !storage.Exists(context.Background(), AudioPath(reciter, slug, chapter, basmala)) || !storage.Exists(context.Background(), TimingsPath(reciter, slug, chapter, basmala)) {
+		return verseKeys, fingerprint, ErrNotGenerated
+	}
+
+	return verseKeys, fingerprint, nil
+}
+
+// constituents lists the verses making up a chapter, in order, starting with
+// the basmala if requested.
+func constituents(reciter string, slug string, chapter int, basmala bool) ([]string, error) {
+	verseKeys := []string{}
+	if basmala {
+		verseKeys = append(verseKeys, basmalaVerseKey)
+	}
+
+	for verse := 1; verse <= quran.VerseCount(chapter); verse++ {
+		verseKeys = append(verseKeys, quran.VerseKey(chapter, verse))
+	}
+
+	missing := 0
+	for _, verseKey := range verseKeys {
+		_, err := storage.Stat(context.Background(), audioPath(reciter, slug, verseKey))
+		if errors.Is(err, os.ErrNotExist) {
+			missing++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if missing > 0 {
+		return nil, fmt.Errorf("%w: %d verses are missing", ErrIncomplete, missing)
+	}
+
+	return verseKeys, nil
+}
+
+// computeFingerprint hashes the key, size and modification time of the audio
+// and timings of every constituent verse, so that any change to them
+// invalidates the generated chapter.
+func computeFingerprint(reciter string, slug string, verseKeys []string) (string, error) {
+	hash := sha256.New()
+
+	for _, verseKey := range verseKeys {
+		for _, path := range []string{audioPath(reciter, slug, verseKey), timingsPath(reciter, slug, verseKey)} {
+			object, err := storage.Stat(context.Background(), path)
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(hash, "%s missing\n", path)
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(hash, "%s %s %d %d\n", path, object.Key, object.Size, object.ModTime.UnixNano())
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func generate(reciter string, slug string, chapter int, basmala bool, verseKeys []string) error {
+	err := os.MkdirAll(dir(reciter, slug), 0755)
+	if err != nil {
+		return err
+	}
+
+	timing := models.ChapterTiming{
+		Chapter: chapter,
+		Verses:  []models.VerseTiming{},
+	}
+
+	list, err := os.CreateTemp(dir(reciter, slug), "concat-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(list.Name())
+	defer list.Close()
+
+	offset := 0.0
+	for i, verseKey := range verseKeys {
+		path, err := fetchAudio(reciter, slug, verseKey)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(list, "file '%s'\n", strings.ReplaceAll(path, "'", `'\''`))
+
+		duration, err := audio.Duration(path)
+		if err != nil {
+			return fmt.Errorf("error probing duration of %s: %w", verseKey, err)
+		}
+
+		verseTiming := models.VerseTiming{
+			VerseKey: verseKey,
+			Start:    offset,
+			End:      offset + duration,
+			Segments: []models.Segment{},
+		}
+
+		verseTimings, err := readTimings(reciter, slug, verseKey)
+		if err != nil {
+			return fmt.Errorf("error reading timings of %s: %w", verseKey, err)
+		}
+		for _, segment := range verseTimings.Segments {
+			segment.Start += offset
+			segment.End += offset
+			verseTiming.Segments = append(verseTiming.Segments, segment)
+		}
+
+		if basmala && i == 0 {
+			timing.Basmala = &verseTiming
+		} else {
+			timing.Verses = append(timing.Verses, verseTiming)
+		}
+		offset += duration
+	}
+
+	err = list.Close()
+	if err != nil {
+		return err
+	}
+
+	// Probing takes a while, so the audio is fetched again in case some of it
+	// was evicted from the storage cache meanwhile.
+	for _, verseKey := range verseKeys {
+		_, err = fetchAudio(reciter, slug, verseKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	audioFilepath := AudioPath(reciter, slug, chapter, basmala)
+	temporaryAudioFilepath := audioFilepath + ".tmp.mp3"
+
+	output, err := exec.Command("ffmpeg", "-y", "-f", "concat", "-safe", "0", "-i", list.Name(), "-c", "copy", temporaryAudioFilepath).CombinedOutput()
+	if err != nil {
+		os.Remove(temporaryAudioFilepath)
+		return fmt.Errorf("error concatenating audio: %w: %s", err, output)
+	}
+
+	jsonData, err := json.Marshal(timing)
+	if err != nil {
+		return err
+	}
+
+	timingsFilepath := TimingsPath(reciter, slug, chapter, basmala)
+	err = os.WriteFile(timingsFilepath+".tmp", jsonData, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(temporaryAudioFilepath, audioFilepath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(timingsFilepath+".tmp", timingsFilepath)
+	if err != nil {
+		return err
+	}
+
+	err = storage.Put(context.Background(), audioFilepath)
+	if err != nil {
+		return err
+	}
+	return storage.Put(context.Background(), timingsFilepath)
+}
+
+// fetchAudio returns the absolute path of a local copy of the audio of a
+// verse.
+func fetchAudio(reciter string, slug string, verseKey string) (string, error) {
+	path, err := storage.Fetch(context.Background(), audioPath(reciter, slug, verseKey))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+func readTimings(reciter string, slug string, verseKey string) (models.Timing, error) {
+	var timing models.Timing
+
+	data, err := storage.ReadFile(context.Background(), timingsPath(reciter, slug, verseKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return timing, nil
+	}
+	if err != nil {
+		return timing, err
+	}
+
+	err = json.Unmarshal(data, &timing)
+	return timing, err
+}
+
+func audioPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, verseKey+".mp3")
+}
+
+func timingsPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, verseKey+".json")
+}
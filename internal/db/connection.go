@@ -8,13 +8,15 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+var DB *sql.DB
 var Queries *sqlc.Queries
 
 func Connect() {
-	db, err := sql.Open("sqlite3", "file:data/db.sqlite?_fk=true")
+	var err error
+	DB, err = sql.Open("sqlite3", "file:data/db.sqlite?_fk=true&_busy_timeout=5000")
 	if err != nil {
 		log.Fatalf("Error connecting to database: %v", err)
 	}
 
-	Queries = sqlc.New(db)
+	Queries = sqlc.New(DB)
 }
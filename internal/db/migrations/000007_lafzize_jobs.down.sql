@@ -0,0 +1 @@
+DROP TABLE lafzize_jobs;
@@ -0,0 +1,12 @@
+CREATE TABLE lafzize_jobs(
+	 id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	 reciter VARCHAR(64) NOT NULL,
+	 slug VARCHAR(64) NOT NULL,
+	 verse_key VARCHAR(6) NOT NULL,
+	 state VARCHAR(16) NOT NULL DEFAULT 'queued',
+	 created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	 updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	 FOREIGN KEY (reciter, slug, verse_key) REFERENCES recitation_files(reciter, slug, verse_key) ON DELETE CASCADE
+);
+
+CREATE INDEX lafzize_jobs_state ON lafzize_jobs(state);
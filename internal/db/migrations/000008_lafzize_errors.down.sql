@@ -0,0 +1,8 @@
+ALTER TABLE recitation_files
+DROP COLUMN lafzize_error;
+
+ALTER TABLE lafzize_jobs
+DROP COLUMN attempts;
+
+ALTER TABLE lafzize_jobs
+DROP COLUMN error;
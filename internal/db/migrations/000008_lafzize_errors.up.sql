@@ -0,0 +1,8 @@
+ALTER TABLE recitation_files
+ADD COLUMN lafzize_error TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE lafzize_jobs
+ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE lafzize_jobs
+ADD COLUMN error TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DROP INDEX lafzize_jobs_batch_id;
+
+ALTER TABLE lafzize_jobs
+DROP COLUMN batch_id;
+
+DROP TABLE lafzize_batches;
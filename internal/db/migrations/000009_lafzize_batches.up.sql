@@ -0,0 +1,12 @@
+CREATE TABLE lafzize_batches(
+	 id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	 reciter VARCHAR(64) NOT NULL,
+	 slug VARCHAR(64) NOT NULL,
+	 created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	 FOREIGN KEY (reciter, slug) REFERENCES recitations(reciter, slug) ON DELETE CASCADE
+);
+
+ALTER TABLE lafzize_jobs
+ADD COLUMN batch_id INTEGER REFERENCES lafzize_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX lafzize_jobs_batch_id ON lafzize_jobs(batch_id);
@@ -0,0 +1,2 @@
+ALTER TABLE recitations
+DROP COLUMN auto_lafzize;
@@ -0,0 +1,2 @@
+ALTER TABLE recitations
+ADD COLUMN auto_lafzize BOOLEAN;
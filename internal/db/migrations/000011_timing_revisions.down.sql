@@ -0,0 +1 @@
+DROP TABLE timing_revisions;
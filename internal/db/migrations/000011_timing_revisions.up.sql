@@ -0,0 +1,13 @@
+CREATE TABLE timing_revisions(
+	 id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	 reciter VARCHAR(64) NOT NULL,
+	 slug VARCHAR(64) NOT NULL,
+	 verse_key VARCHAR(6) NOT NULL,
+	 author VARCHAR(64) NOT NULL,
+	 source VARCHAR(16) NOT NULL,
+	 timing TEXT NOT NULL,
+	 created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	 FOREIGN KEY (reciter, slug, verse_key) REFERENCES recitation_files(reciter, slug, verse_key) ON DELETE CASCADE
+);
+
+CREATE INDEX timing_revisions_recitation_file ON timing_revisions(reciter, slug, verse_key);
@@ -0,0 +1,2 @@
+DROP TRIGGER recitation_ids_insert;
+DROP TABLE recitation_ids;
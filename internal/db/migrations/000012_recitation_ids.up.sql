@@ -0,0 +1,15 @@
+CREATE TABLE recitation_ids(
+	 id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	 reciter VARCHAR(64) NOT NULL,
+	 slug VARCHAR(64) NOT NULL,
+	 UNIQUE(reciter, slug),
+	 FOREIGN KEY (reciter, slug) REFERENCES recitations(reciter, slug) ON DELETE CASCADE
+);
+
+INSERT INTO recitation_ids(reciter, slug)
+	SELECT reciter, slug FROM recitations;
+
+CREATE TRIGGER recitation_ids_insert AFTER INSERT ON recitations
+BEGIN
+	INSERT INTO recitation_ids(reciter, slug) VALUES (NEW.reciter, NEW.slug);
+END;
@@ -0,0 +1,14 @@
+ALTER TABLE recitation_files
+DROP COLUMN duration;
+
+ALTER TABLE recitation_files
+DROP COLUMN bit_rate;
+
+ALTER TABLE recitation_files
+DROP COLUMN sample_rate;
+
+ALTER TABLE recitation_files
+DROP COLUMN channels;
+
+ALTER TABLE recitation_files
+DROP COLUMN size;
@@ -0,0 +1,14 @@
+ALTER TABLE recitation_files
+ADD COLUMN duration REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE recitation_files
+ADD COLUMN bit_rate INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE recitation_files
+ADD COLUMN sample_rate INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE recitation_files
+ADD COLUMN channels INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE recitation_files
+ADD COLUMN size INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE recitation_files
+DROP COLUMN transcode_status;
+
+ALTER TABLE recitation_files
+DROP COLUMN transcode_error;
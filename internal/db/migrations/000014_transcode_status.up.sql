@@ -0,0 +1,5 @@
+ALTER TABLE recitation_files
+ADD COLUMN transcode_status TEXT NOT NULL DEFAULT 'done';
+
+ALTER TABLE recitation_files
+ADD COLUMN transcode_error TEXT NOT NULL DEFAULT '';
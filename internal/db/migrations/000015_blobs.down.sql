@@ -0,0 +1,5 @@
+DROP TRIGGER recitation_file_blobs_delete;
+DROP TRIGGER recitation_file_blobs_update;
+DROP TRIGGER recitation_file_blobs_insert;
+DROP TABLE recitation_file_blobs;
+DROP TABLE blobs;
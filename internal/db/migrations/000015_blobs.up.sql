@@ -0,0 +1,37 @@
+CREATE TABLE blobs(
+	 hash VARCHAR(64) NOT NULL,
+	 extension VARCHAR(16) NOT NULL,
+	 size INTEGER NOT NULL,
+	 ref_count INTEGER NOT NULL DEFAULT 0,
+	 created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	 PRIMARY KEY (hash, extension)
+);
+
+CREATE TABLE recitation_file_blobs(
+	 reciter VARCHAR(64) NOT NULL,
+	 slug VARCHAR(64) NOT NULL,
+	 verse_key VARCHAR(6) NOT NULL,
+	 extension VARCHAR(16) NOT NULL,
+	 hash VARCHAR(64) NOT NULL,
+	 PRIMARY KEY (reciter, slug, verse_key, extension),
+	 FOREIGN KEY (reciter, slug, verse_key) REFERENCES recitation_files(reciter, slug, verse_key) ON DELETE CASCADE,
+	 FOREIGN KEY (hash, extension) REFERENCES blobs(hash, extension)
+);
+
+CREATE INDEX recitation_file_blobs_hash ON recitation_file_blobs(hash, extension);
+
+CREATE TRIGGER recitation_file_blobs_insert AFTER INSERT ON recitation_file_blobs
+BEGIN
+	UPDATE blobs SET ref_count = ref_count + 1 WHERE hash = NEW.hash AND extension = NEW.extension;
+END;
+
+CREATE TRIGGER recitation_file_blobs_update AFTER UPDATE OF hash, extension ON recitation_file_blobs
+BEGIN
+	UPDATE blobs SET ref_count = ref_count - 1 WHERE hash = OLD.hash AND extension = OLD.extension;
+	UPDATE blobs SET ref_count = ref_count + 1 WHERE hash = NEW.hash AND extension = NEW.extension;
+END;
+
+CREATE TRIGGER recitation_file_blobs_delete AFTER DELETE ON recitation_file_blobs
+BEGIN
+	UPDATE blobs SET ref_count = ref_count - 1 WHERE hash = OLD.hash AND extension = OLD.extension;
+END;
@@ -0,0 +1,38 @@
+-- name: BlobCreateBlob :exec
+INSERT INTO blobs(hash, extension, size)
+	VALUES (?1, ?2, ?3)
+ON CONFLICT(hash, extension) DO NOTHING;
+
+-- name: BlobSelectUnreferencedBlobs :many
+SELECT
+	*
+FROM
+	blobs
+WHERE
+	ref_count <= 0;
+
+-- name: BlobDeleteUnreferencedBlob :execrows
+DELETE FROM blobs
+WHERE hash = ?1 AND extension = ?2 AND ref_count <= 0;
+
+-- name: BlobSelectBlobs :many
+SELECT
+	*
+FROM
+	blobs;
+
+-- name: BlobSelectMiscountedBlobs :many
+SELECT
+	blobs.*,
+	(SELECT COUNT(*) FROM recitation_file_blobs WHERE recitation_file_blobs.hash = blobs.hash AND recitation_file_blobs.extension = blobs.extension) AS references_count
+FROM
+	blobs
+WHERE
+	ref_count != (SELECT COUNT(*) FROM recitation_file_blobs WHERE recitation_file_blobs.hash = blobs.hash AND recitation_file_blobs.extension = blobs.extension);
+
+-- name: BlobRecountBlob :exec
+UPDATE blobs
+SET
+	ref_count = (SELECT COUNT(*) FROM recitation_file_blobs WHERE recitation_file_blobs.hash = blobs.hash AND recitation_file_blobs.extension = blobs.extension)
+WHERE
+	hash = ?1 AND extension = ?2;
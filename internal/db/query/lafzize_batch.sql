@@ -0,0 +1,26 @@
+-- name: LafzizeBatchCreateLafzizeBatch :one
+INSERT INTO lafzize_batches(reciter, slug)
+	VALUES (?1, ?2)
+RETURNING *;
+
+-- name: LafzizeBatchDeleteLafzizeBatch :exec
+DELETE FROM lafzize_batches
+WHERE id = ?1;
+
+-- name: LafzizeBatchSelectLafzizeBatch :one
+SELECT
+	*
+FROM
+	lafzize_batches
+WHERE
+	id = ?1;
+
+-- name: LafzizeBatchCountLafzizeJobs :many
+SELECT
+	state, COUNT(*) AS count
+FROM
+	lafzize_jobs
+WHERE
+	batch_id = ?1
+GROUP BY
+	state;
@@ -0,0 +1,69 @@
+-- name: LafzizeJobCreateLafzizeJob :one
+INSERT INTO lafzize_jobs(reciter, slug, verse_key, batch_id)
+	VALUES (?1, ?2, ?3, ?4)
+RETURNING *;
+
+-- name: LafzizeJobSelectLafzizeJob :one
+SELECT
+	*
+FROM
+	lafzize_jobs
+WHERE
+	id = ?1;
+
+-- name: LafzizeJobSelectLatestLafzizeJob :one
+SELECT
+	*
+FROM
+	lafzize_jobs
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3
+ORDER BY
+	id DESC
+LIMIT 1;
+
+-- name: LafzizeJobClaimLafzizeJob :one
+UPDATE lafzize_jobs
+SET
+	state = 'running',
+	updated_at = CURRENT_TIMESTAMP
+WHERE
+	id = (
+		SELECT
+			id
+		FROM
+			lafzize_jobs
+		WHERE
+			state = 'queued'
+		ORDER BY
+			id
+		LIMIT 1)
+RETURNING *;
+
+-- name: LafzizeJobUpdateLafzizeJob :one
+UPDATE lafzize_jobs
+SET
+	state = ?2,
+	attempts = ?3,
+	error = ?4,
+	updated_at = CURRENT_TIMESTAMP
+WHERE
+	id = ?1
+RETURNING *;
+
+-- name: LafzizeJobCancelQueuedLafzizeJob :one
+UPDATE lafzize_jobs
+SET
+	state = 'cancelled',
+	updated_at = CURRENT_TIMESTAMP
+WHERE
+	id = ?1 AND state = 'queued'
+RETURNING *;
+
+-- name: LafzizeJobRequeueRunningLafzizeJobs :exec
+UPDATE lafzize_jobs
+SET
+	state = 'queued',
+	updated_at = CURRENT_TIMESTAMP
+WHERE
+	state = 'running';
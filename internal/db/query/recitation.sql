@@ -20,7 +20,8 @@ WHERE
 -- name: RecitationUpdateRecitation :one
 UPDATE recitations
 SET
-	name = ?3
+	name = ?3,
+	auto_lafzize = ?4
 WHERE
 	reciter = ?1 AND slug = ?2
 RETURNING *;
@@ -1,6 +1,6 @@
 -- name: RecitationFileCreateRecitationFile :one
-INSERT INTO recitation_files(reciter, slug, verse_key)
-	VALUES (?1, ?2, ?3)
+INSERT INTO recitation_files(reciter, slug, verse_key, transcode_status)
+	VALUES (?1, ?2, ?3, 'uploading')
 RETURNING *;
 
 -- name: RecitationFileSelectRecitationFiles :many
@@ -28,8 +28,156 @@ WHERE
 	reciter = ?1 AND slug = ?2 AND verse_key = ?3
 RETURNING *;
 
+-- name: RecitationFileUpdateLafzizeError :exec
+UPDATE recitation_files
+SET
+	lafzize_error = ?4
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3;
+
+-- name: RecitationFileUpdateMetadata :exec
+UPDATE recitation_files
+SET
+	duration = ?4,
+	bit_rate = ?5,
+	sample_rate = ?6,
+	channels = ?7,
+	size = ?8
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3;
+
 -- name: RecitationFileDeleteRecitationFile :one
 DELETE FROM recitation_files
 WHERE
 	reciter = ?1 AND slug = ?2 AND verse_key = ?3
 RETURNING *;
+
+-- name: RecitationFileResetStaleLafzizeProcessing :exec
+UPDATE recitation_files
+SET
+	lafzize_processing = 0
+WHERE
+	lafzize_processing = 1 AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			lafzize_jobs
+		WHERE
+			lafzize_jobs.reciter = recitation_files.reciter
+			AND lafzize_jobs.slug = recitation_files.slug
+			AND lafzize_jobs.verse_key = recitation_files.verse_key
+			AND lafzize_jobs.state IN ('queued', 'running'));
+
+-- name: RecitationFileResetLafzizeProcessing :exec
+UPDATE recitation_files
+SET
+	lafzize_processing = 0
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND lafzize_processing = 1 AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			lafzize_jobs
+		WHERE
+			lafzize_jobs.reciter = recitation_files.reciter
+			AND lafzize_jobs.slug = recitation_files.slug
+			AND lafzize_jobs.verse_key = recitation_files.verse_key
+			AND lafzize_jobs.state IN ('queued', 'running'));
+
+-- name: RecitationFileUpdateTranscodeStatus :exec
+UPDATE recitation_files
+SET
+	transcode_status = ?4,
+	transcode_error = ?5
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3;
+
+-- name: RecitationFileClaimTranscode :one
+UPDATE recitation_files
+SET
+	transcode_status = 'running'
+WHERE
+	rowid = (
+		SELECT
+			rowid
+		FROM
+			recitation_files
+		WHERE
+			transcode_status = 'pending'
+		ORDER BY
+			rowid
+		LIMIT 1)
+RETURNING *;
+
+-- name: RecitationFileRequeueRunningTranscodes :exec
+UPDATE recitation_files
+SET
+	transcode_status = 'pending'
+WHERE
+	transcode_status = 'running';
+
+-- name: RecitationFileRequeueFailedTranscode :execrows
+UPDATE recitation_files
+SET
+	transcode_status = 'pending',
+	transcode_error = ''
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND transcode_status = 'failed';
+
+-- name: RecitationFileFailInterruptedUploads :exec
+UPDATE recitation_files
+SET
+	transcode_status = 'failed',
+	transcode_error = 'the upload was interrupted'
+WHERE
+	transcode_status = 'uploading';
+
+-- name: RecitationFileSelectRecitationFilesWithoutBlobs :many
+SELECT
+	*
+FROM
+	recitation_files
+WHERE
+	transcode_status = 'done'
+	AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			recitation_file_blobs
+		WHERE
+			recitation_file_blobs.reciter = recitation_files.reciter
+			AND recitation_file_blobs.slug = recitation_files.slug
+			AND recitation_file_blobs.verse_key = recitation_files.verse_key);
+
+-- name: RecitationFileSelectAllRecitationFiles :many
+SELECT
+	*
+FROM
+	recitation_files
+ORDER BY
+	reciter, slug, verse_key;
+
+-- name: RecitationFileSelectStaleLafzizeProcessing :many
+SELECT
+	*
+FROM
+	recitation_files
+WHERE
+	lafzize_processing = 1 AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			lafzize_jobs
+		WHERE
+			lafzize_jobs.reciter = recitation_files.reciter
+			AND lafzize_jobs.slug = recitation_files.slug
+			AND lafzize_jobs.verse_key = recitation_files.verse_key
+			AND lafzize_jobs.state IN ('queued', 'running'));
+
+-- name: RecitationFileMarkLafzizeProcessing :execrows
+UPDATE recitation_files
+SET
+	lafzize_processing = 1,
+	lafzize_error = ''
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND lafzize_processing = 0;
@@ -0,0 +1,22 @@
+-- name: RecitationFileBlobUpsertRecitationFileBlob :exec
+INSERT INTO recitation_file_blobs(reciter, slug, verse_key, extension, hash)
+	VALUES (?1, ?2, ?3, ?4, ?5)
+ON CONFLICT(reciter, slug, verse_key, extension)
+	DO UPDATE SET
+		hash = excluded.hash
+	WHERE
+		hash != excluded.hash;
+
+-- name: RecitationFileBlobSelectHash :one
+SELECT
+	hash
+FROM
+	recitation_file_blobs
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND extension = ?4;
+
+-- name: RecitationFileBlobSelectRecitationFileBlobs :many
+SELECT
+	*
+FROM
+	recitation_file_blobs;
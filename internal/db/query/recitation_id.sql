@@ -0,0 +1,19 @@
+-- name: RecitationIDSelectRecitations :many
+SELECT
+	recitation_ids.id, recitations.reciter, recitations.slug, recitations.name, users.displayname
+FROM
+	recitation_ids
+	JOIN recitations ON recitations.reciter = recitation_ids.reciter AND recitations.slug = recitation_ids.slug
+	JOIN users ON users.username = recitations.reciter
+ORDER BY
+	recitation_ids.id;
+
+-- name: RecitationIDSelectRecitation :one
+SELECT
+	recitation_ids.id, recitations.reciter, recitations.slug, recitations.name, users.displayname
+FROM
+	recitation_ids
+	JOIN recitations ON recitations.reciter = recitation_ids.reciter AND recitations.slug = recitation_ids.slug
+	JOIN users ON users.username = recitations.reciter
+WHERE
+	recitation_ids.id = ?1;
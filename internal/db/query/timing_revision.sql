@@ -0,0 +1,22 @@
+-- name: TimingRevisionCreateTimingRevision :one
+INSERT INTO timing_revisions(reciter, slug, verse_key, author, source, timing)
+	VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+RETURNING *;
+
+-- name: TimingRevisionSelectTimingRevisions :many
+SELECT
+	id, reciter, slug, verse_key, author, source, created_at
+FROM
+	timing_revisions
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3
+ORDER BY
+	id DESC;
+
+-- name: TimingRevisionSelectTimingRevision :one
+SELECT
+	*
+FROM
+	timing_revisions
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND id = ?4;
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"sync"
+)
+
+const (
+	TypeUploaded         = "uploaded"
+	TypeTranscoded       = "transcoded"
+	TypeTranscodeFailed  = "transcode_failed"
+	TypeLafzizeQueued    = "lafzize_queued"
+	TypeLafzizeSucceeded = "lafzize_succeeded"
+	TypeLafzizeFailed    = "lafzize_failed"
+	TypeLafzizeCancelled = "lafzize_cancelled"
+	TypeTimingsUpdated   = "timings_updated"
+)
+
+// Number of events buffered per subscriber before further events are dropped.
+const bufferSize = 64
+
+type Event struct {
+	Type     string `json:"type"`
+	Reciter  string `json:"reciter"`
+	Slug     string `json:"slug"`
+	VerseKey string `json:"verse_key"`
+	Error    string `json:"error,omitempty"`
+}
+
+var mutex sync.Mutex
+var subscribers = map[string]map[chan Event]struct{}{}
+
+// Subscribe returns a channel receiving events of a recitation, along with a
+// function that must be called once the subscriber is done.
+func Subscribe(reciter string, slug string) (<-chan Event, func()) {
+	channel := make(chan Event, bufferSize)
+	key := recitationKey(reciter, slug)
+
+	mutex.Lock()
+	if subscribers[key] == nil {
+		subscribers[key] = map[chan Event]struct{}{}
+	}
+	subscribers[key][channel] = struct{}{}
+	mutex.Unlock()
+
+	unsubscribe := func() {
+		mutex.Lock()
+		delete(subscribers[key], channel)
+		if len(subscribers[key]) == 0 {
+			delete(subscribers, key)
+		}
+		mutex.Unlock()
+	}
+
+	return channel, unsubscribe
+}
+
+// Publish sends an event to every subscriber of its recitation without
+// blocking on slow subscribers.
+func Publish(event Event) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for channel := range subscribers[recitationKey(event.Reciter, event.Slug)] {
+		select {
+		case channel <- event:
+		default:
+		}
+	}
+}
+
+func recitationKey(reciter string, slug string) string {
+	return reciter + "/" + slug
+}
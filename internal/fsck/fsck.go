@@ -0,0 +1,571 @@
+package fsck
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/blobs"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/lafzize"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/transcode"
+)
+
+const (
+	// A recitation file whose transcoded audio is missing in any profile, or
+	// whose master is missing while masters are kept. It is transcoded again
+	// from its master, or from the audio left in another profile, and marked
+	// as failed if there is none.
+	KindMissingAudio = "missing_audio"
+	// A recitation file whose has_timings flag disagrees with the existence of
+	// its timings. The flag is corrected.
+	KindStaleTimingsFlag = "stale_timings_flag"
+	// A recitation file marked as being lafzized without a queued or running
+	// lafzize job. The mark is cleared.
+	KindStuckLafzize = "stuck_lafzize_processing"
+	// A file of the uploads that belongs to no recitation file. It is deleted.
+	KindOrphanFile = "orphan_file"
+	// A directory of the uploads that belongs to no recitation. It is deleted.
+	KindOrphanDirectory = "orphan_directory"
+	// An upload that is neither awaiting transcoding nor kept to retry a failed
+	// transcode. It is deleted.
+	KindStrayRaw = "stray_raw"
+	// A temporary file left behind by an interrupted write. It is deleted.
+	KindStrayTemporary = "stray_temporary"
+	// The staging directory of a unit of work that was never finished. It is
+	// deleted.
+	KindStaleStaging = "stale_staging"
+	// A blob referenced by a recitation file that does not exist. It is
+	// recreated from the audio of the recitation file.
+	KindMissingBlob = "missing_blob"
+	// A blob file that is not recorded in the database. It is deleted.
+	KindOrphanBlob = "orphan_blob"
+	// A blob whose reference count disagrees with its references. It is
+	// recounted.
+	KindMiscountedBlob = "miscounted_blob"
+)
+
+// Temporary files, uploads awaiting transcoding and staging directories
+// younger than this are assumed to be in use.
+const staleAge = time.Hour
+
+var (
+	uploadsDir = filepath.Join("data", "uploads")
+	blobsDir   = filepath.Join("data", "blobs")
+	stagingDir = filepath.Join("data", "staging")
+)
+
+// Issue is an inconsistency between the database and the files.
+type Issue struct {
+	Kind     string `json:"kind"`
+	Path     string `json:"path"`
+	Detail   string `json:"detail"`
+	Repaired bool   `json:"repaired"`
+	Error    string `json:"error,omitempty"`
+
+	repair func(ctx context.Context) error
+}
+
+type Report struct {
+	Issues   []Issue `json:"issues"`
+	Repaired int     `json:"repaired"`
+}
+
+// Check scans the database and the uploads for inconsistencies, repairing
+// them if asked to.
+func Check(ctx context.Context, repair bool) (Report, error) {
+	report := Report{Issues: []Issue{}}
+
+	for _, check := range []func(context.Context) ([]Issue, error){
+		checkRecitationFiles,
+		checkStuckLafzize,
+		checkUploads,
+		checkBlobs,
+		checkStaging,
+	} {
+		issues, err := check(ctx)
+		if err != nil {
+			return report, err
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	if !repair {
+		return report, nil
+	}
+
+	for i := range report.Issues {
+		issue := &report.Issues[i]
+
+		err := issue.repair(ctx)
+		if err != nil {
+			issue.Error = err.Error()
+			continue
+		}
+		issue.Repaired = true
+		report.Repaired++
+	}
+
+	return report, nil
+}
+
+func checkRecitationFiles(ctx context.Context) ([]Issue, error) {
+	recitationFiles, err := db.Queries.RecitationFileSelectAllRecitationFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []Issue{}
+	for _, recitationFile := range recitationFiles {
+		reciter, slug, verseKey := recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey
+
+		if recitationFile.TranscodeStatus == transcode.StatusDone {
+			missing := missingAudio(ctx, reciter, slug, verseKey)
+			if len(missing) > 0 {
+				issues = append(issues, Issue{
+					Kind:   KindMissingAudio,
+					Path:   missing[0],
+					Detail: fmt.Sprintf("the recitation file is transcoded but %s does not exist", strings.Join(missing, ", ")),
+					repair: func(ctx context.Context) error {
+						return retranscode(ctx, recitationFile)
+					},
+				})
+			}
+		}
+
+		timingsFilepath := filepath.Join(uploadsDir, reciter, slug, verseKey+".json")
+		hasTimings := storage.Exists(ctx, timingsFilepath)
+		if recitationFile.HasTimings != hasTimings {
+			issues = append(issues, Issue{
+				Kind:   KindStaleTimingsFlag,
+				Path:   timingsFilepath,
+				Detail: fmt.Sprintf("has_timings is %t but the timings %s", recitationFile.HasTimings, existence(hasTimings)),
+				repair: func(ctx context.Context) error {
+					_, err := db.Queries.RecitationFileUpdateRecitationFile(ctx, sqlc.RecitationFileUpdateRecitationFileParams{
+						Reciter:           reciter,
+						Slug:              slug,
+						VerseKey:          verseKey,
+						HasTimings:        hasTimings,
+						LafzizeProcessing: recitationFile.LafzizeProcessing,
+					})
+					return err
+				},
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// missingAudio returns the audio of a verse that is missing, in every profile
+// and as its master if masters are kept.
+func missingAudio(ctx context.Context, reciter string, slug string, verseKey string) []string {
+	audioFilepaths := []string{}
+	for _, profile := range audio.Profiles() {
+		audioFilepaths = append(audioFilepaths, profile.Path(reciter, slug, verseKey))
+	}
+	if audio.KeepMaster() {
+		audioFilepaths = append(audioFilepaths, audio.MasterPath(reciter, slug, verseKey))
+	}
+
+	missing := []string{}
+	for _, audioFilepath := range audioFilepaths {
+		if !storage.Exists(ctx, audioFilepath) {
+			missing = append(missing, audioFilepath)
+		}
+	}
+	return missing
+}
+
+// retranscode queues a recitation file whose audio is missing to be transcoded
+// from its master, or from the audio of another profile if no master was kept,
+// and marks it as failed if there is neither.
+func retranscode(ctx context.Context, recitationFile sqlc.RecitationFile) error {
+	reciter, slug, verseKey := recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey
+
+	source := ""
+	for _, audioFilepath := range audio.Paths(reciter, slug, verseKey) {
+		if storage.Exists(ctx, audioFilepath) {
+			source = audioFilepath
+			break
+		}
+	}
+	if source == "" {
+		return db.Queries.RecitationFileUpdateTranscodeStatus(ctx, sqlc.RecitationFileUpdateTranscodeStatusParams{
+			Reciter:         reciter,
+			Slug:            slug,
+			VerseKey:        verseKey,
+			TranscodeStatus: transcode.StatusFailed,
+			TranscodeError:  "the audio is missing and no master was kept",
+		})
+	}
+
+	// The source is copied rather than linked, as the transcode moves the
+	// upload over the master and replaces the audio of every profile.
+	err := copyFile(ctx, source, transcode.RawPath(reciter, slug, verseKey))
+	if err != nil {
+		return err
+	}
+
+	return db.Queries.RecitationFileUpdateTranscodeStatus(ctx, sqlc.RecitationFileUpdateTranscodeStatusParams{
+		Reciter:         reciter,
+		Slug:            slug,
+		VerseKey:        verseKey,
+		TranscodeStatus: transcode.StatusPending,
+		TranscodeError:  "",
+	})
+}
+
+func checkStuckLafzize(ctx context.Context) ([]Issue, error) {
+	recitationFiles, err := db.Queries.RecitationFileSelectStaleLafzizeProcessing(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []Issue{}
+	for _, recitationFile := range recitationFiles {
+		issues = append(issues, Issue{
+			Kind:   KindStuckLafzize,
+			Path:   audio.PrimaryPath(recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey),
+			Detail: fmt.Sprintf("lafzize_processing is set but there is no %s or %s lafzize job", lafzize.StateQueued, lafzize.StateRunning),
+			repair: func(ctx context.Context) error {
+				return db.Queries.RecitationFileResetLafzizeProcessing(ctx, sqlc.RecitationFileResetLafzizeProcessingParams{
+					Reciter:  recitationFile.Reciter,
+					Slug:     recitationFile.Slug,
+					VerseKey: recitationFile.VerseKey,
+				})
+			},
+		})
+	}
+
+	return issues, nil
+}
+
+// checkUploads walks data/uploads/{reciter}/{slug}, looking for files and
+// directories that do not belong to any recitation or recitation file.
+func checkUploads(ctx context.Context) ([]Issue, error) {
+	recitations, err := db.Queries.RecitationSelectRecitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recitationExists := map[string]bool{}
+	for _, recitation := range recitations {
+		recitationExists[filepath.Join(recitation.Reciter, recitation.Slug)] = true
+	}
+
+	recitationFiles, err := db.Queries.RecitationFileSelectAllRecitationFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recitationFileStatus := map[string]string{}
+	for _, recitationFile := range recitationFiles {
+		recitationFileStatus[filepath.Join(recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey)] = recitationFile.TranscodeStatus
+	}
+
+	issues := []Issue{}
+
+	reciterEntries, err := storage.ReadDir(ctx, uploadsDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, reciterEntry := range reciterEntries {
+		reciterPath := filepath.Join(uploadsDir, reciterEntry.Name)
+		if !reciterEntry.IsDir {
+			issues = append(issues, orphanFile(reciterPath, "files are not expected outside of recitations"))
+			continue
+		}
+
+		slugEntries, err := storage.ReadDir(ctx, reciterPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, slugEntry := range slugEntries {
+			recitation := filepath.Join(reciterEntry.Name, slugEntry.Name)
+			slugPath := filepath.Join(uploadsDir, recitation)
+
+			if !slugEntry.IsDir {
+				issues = append(issues, orphanFile(slugPath, "files are not expected outside of recitations"))
+				continue
+			}
+
+			if !recitationExists[recitation] {
+				issues = append(issues, Issue{
+					Kind:   KindOrphanDirectory,
+					Path:   slugPath,
+					Detail: "the recitation does not exist",
+					repair: func(ctx context.Context) error {
+						_, err := db.Queries.RecitationSelectRecitation(ctx, sqlc.RecitationSelectRecitationParams{
+							Reciter: reciterEntry.Name,
+							Slug:    slugEntry.Name,
+						})
+						if err == nil {
+							return errors.New("the recitation was created since the check")
+						}
+						if !errors.Is(err, sql.ErrNoRows) {
+							return err
+						}
+						return storage.Remove(ctx, slugPath)
+					},
+				})
+				continue
+			}
+
+			fileIssues, err := checkRecitationDir(ctx, slugPath, recitation, recitationFileStatus)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, fileIssues...)
+		}
+	}
+
+	return issues, nil
+}
+
+func checkRecitationDir(ctx context.Context, dir string, recitation string, recitationFileStatus map[string]string) ([]Issue, error) {
+	entries, err := storage.ReadDir(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []Issue{}
+	for _, entry := range entries {
+		name := entry.Name
+		path := filepath.Join(dir, name)
+
+		// Chapters are a cache that is regenerated as needed.
+		if entry.IsDir && name == "chapters" {
+			continue
+		}
+		if entry.IsDir {
+			issues = append(issues, orphanFile(path, "directories are not expected in recitations"))
+			continue
+		}
+
+		recent := time.Since(entry.ModTime) < staleAge
+
+		if strings.Contains(name, ".tmp") {
+			if !recent {
+				issues = append(issues, Issue{
+					Kind:   KindStrayTemporary,
+					Path:   path,
+					Detail: "the temporary file is older than " + staleAge.String(),
+					repair: removeFunc(path),
+				})
+			}
+			continue
+		}
+
+		verseKey, _, _ := strings.Cut(name, ".")
+		status, hasRecitationFile := recitationFileStatus[filepath.Join(recitation, verseKey)]
+
+		if strings.HasSuffix(name, ".raw") {
+			switch {
+			case hasRecitationFile && (status == transcode.StatusUploading || status == transcode.StatusPending || status == transcode.StatusRunning || status == transcode.StatusFailed):
+			case recent:
+			default:
+				issues = append(issues, Issue{
+					Kind:   KindStrayRaw,
+					Path:   path,
+					Detail: "the upload is neither awaiting transcoding nor kept to retry it",
+					repair: removeFunc(path),
+				})
+			}
+			continue
+		}
+
+		if !hasRecitationFile {
+			issues = append(issues, orphanFile(path, "the recitation file does not exist"))
+		}
+	}
+
+	return issues, nil
+}
+
+func orphanFile(path string, detail string) Issue {
+	return Issue{
+		Kind:   KindOrphanFile,
+		Path:   path,
+		Detail: detail,
+		repair: func(ctx context.Context) error {
+			relative, err := filepath.Rel(uploadsDir, path)
+			if err != nil {
+				return err
+			}
+
+			// Check again, in case the recitation file was created since.
+			parts := strings.Split(relative, string(filepath.Separator))
+			if len(parts) == 3 {
+				verseKey, _, _ := strings.Cut(parts[2], ".")
+				_, err := db.Queries.RecitationFileSelectRecitationFile(ctx, sqlc.RecitationFileSelectRecitationFileParams{
+					Reciter:  parts[0],
+					Slug:     parts[1],
+					VerseKey: verseKey,
+				})
+				if err == nil {
+					return errors.New("the recitation file was created since the check")
+				}
+				if !errors.Is(err, sql.ErrNoRows) {
+					return err
+				}
+			}
+
+			return storage.Remove(ctx, path)
+		},
+	}
+}
+
+func checkBlobs(ctx context.Context) ([]Issue, error) {
+	issues := []Issue{}
+
+	recitationFileBlobs, err := db.Queries.RecitationFileBlobSelectRecitationFileBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, recitationFileBlob := range recitationFileBlobs {
+		blobFilepath := blobs.Path(recitationFileBlob.Hash, recitationFileBlob.Extension)
+		if storage.Exists(ctx, blobFilepath) {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Kind:   KindMissingBlob,
+			Path:   blobFilepath,
+			Detail: fmt.Sprintf("the blob of %s/%s/%s.%s does not exist", recitationFileBlob.Reciter, recitationFileBlob.Slug, recitationFileBlob.VerseKey, recitationFileBlob.Extension),
+			repair: func(ctx context.Context) error {
+				return blobs.Adopt(ctx, recitationFileBlob.Reciter, recitationFileBlob.Slug, recitationFileBlob.VerseKey)
+			},
+		})
+	}
+
+	miscountedBlobs, err := db.Queries.BlobSelectMiscountedBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range miscountedBlobs {
+		issues = append(issues, Issue{
+			Kind:   KindMiscountedBlob,
+			Path:   blobs.Path(blob.Hash, blob.Extension),
+			Detail: fmt.Sprintf("ref_count is %d but the blob has %d references", blob.RefCount, blob.ReferencesCount),
+			repair: func(ctx context.Context) error {
+				return db.Queries.BlobRecountBlob(ctx, sqlc.BlobRecountBlobParams{
+					Hash:      blob.Hash,
+					Extension: blob.Extension,
+				})
+			},
+		})
+	}
+
+	knownBlobs, err := db.Queries.BlobSelectBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, blob := range knownBlobs {
+		known[blobs.Path(blob.Hash, blob.Extension)] = true
+	}
+
+	prefixEntries, err := storage.ReadDir(ctx, blobsDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, prefixEntry := range prefixEntries {
+		entries, err := storage.ReadDir(ctx, filepath.Join(blobsDir, prefixEntry.Name))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(blobsDir, prefixEntry.Name, entry.Name)
+			if known[path] {
+				continue
+			}
+
+			// A blob is linked before it is recorded, so recent blobs may still
+			// be being adopted.
+			if time.Since(entry.ModTime) < staleAge {
+				continue
+			}
+
+			issues = append(issues, Issue{
+				Kind:   KindOrphanBlob,
+				Path:   path,
+				Detail: "the blob is not recorded in the database",
+				repair: removeFunc(path),
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+func checkStaging(ctx context.Context) ([]Issue, error) {
+	entries, err := os.ReadDir(stagingDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []Issue{}
+	for _, entry := range entries {
+		fileInfo, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(fileInfo.ModTime()) < staleAge {
+			continue
+		}
+
+		path := filepath.Join(stagingDir, entry.Name())
+		issues = append(issues, Issue{
+			Kind:   KindStaleStaging,
+			Path:   path,
+			Detail: "the unit of work is older than " + staleAge.String(),
+			repair: removeFunc(path),
+		})
+	}
+
+	return issues, nil
+}
+
+func copyFile(ctx context.Context, source string, destination string) error {
+	sourceFile, err := storage.Open(ctx, source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destinationFile, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(destinationFile, sourceFile)
+	if closeErr := destinationFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func removeFunc(path string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return storage.Remove(ctx, path)
+	}
+}
+
+func existence(exists bool) string {
+	if exists {
+		return "exist"
+	}
+	return "do not exist"
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/archive"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// GetRecitationArchive godoc
+//
+//	@Tags		Recitation
+//	@Produce	application/zip
+//	@Produce	application/x-tar
+//	@Produce	application/gzip
+//
+//	@Param		reciter	path	string	true	"Reciter"
+//	@Param		slug	path	string	true	"Slug"
+//	@Param		format	query	string	false	"Archive format"	Enums(zip, tar, tar.gz)	default(zip)
+//	@Param		chapter	query	int		false	"Only include verses of this chapter"
+//
+//	@Success	200
+//	@Failure	400	{object}	models.Error
+//	@Failure	500	{object}	models.Error
+//	@Router		/recitations/{reciter}/{slug}/archive [get]
+func GetRecitationArchive(w http.ResponseWriter, r *http.Request) {
+	reciter := chi.URLParam(r, "reciter")
+	slug := chi.URLParam(r, "slug")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = archive.FormatZip
+	}
+
+	chapter := 0
+	if value := r.URL.Query().Get("chapter"); value != "" {
+		var err error
+		chapter, err = strconv.Atoi(value)
+		if err == nil && quran.VerseCount(chapter) == 0 {
+			err = fmt.Errorf("there is no chapter %d", chapter)
+		}
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, render.M{
+				"message": "Invalid chapter",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	recitation, err := db.Queries.RecitationSelectRecitation(context.Background(), sqlc.RecitationSelectRecitationParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recitationFiles, err := db.Queries.RecitationFileSelectRecitationFiles(context.Background(), sqlc.RecitationFileSelectRecitationFilesParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation files",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	uploadsDir := filepath.Join("data", "uploads", reciter, slug)
+
+	type archiveEntry struct {
+		position [2]int
+		file     models.ArchiveFile
+	}
+
+	entries := []archiveEntry{}
+	for _, recitationFile := range recitationFiles {
+		fileChapter, verse, err := quran.ParseVerseKey(recitationFile.VerseKey)
+		if err != nil || (chapter != 0 && fileChapter != chapter) {
+			continue
+		}
+
+		audioObject, err := storage.Stat(context.Background(), filepath.Join(uploadsDir, recitationFile.VerseKey+".mp3"))
+		if err != nil {
+			log.Printf("Error archiving audio file of %s/%s/%s, skipping it: %v\n", reciter, slug, recitationFile.VerseKey, err)
+			continue
+		}
+
+		file := models.ArchiveFile{
+			VerseKey:   recitationFile.VerseKey,
+			Audio:      recitationFile.VerseKey + ".mp3",
+			AudioSize:  audioObject.Size,
+			HasTimings: recitationFile.HasTimings,
+		}
+		if recitationFile.HasTimings {
+			file.Timings = recitationFile.VerseKey + ".json"
+		}
+
+		entries = append(entries, archiveEntry{position: [2]int{fileChapter, verse}, file: file})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return comparePositions(entries[i].position, entries[j].position) < 0
+	})
+
+	manifest := models.ArchiveManifest{
+		Recitation: models.ArchiveRecitation{
+			Reciter: recitation.Reciter,
+			Slug:    recitation.Slug,
+			Name:    recitation.Name,
+		},
+		Files:     []models.ArchiveFile{},
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, entry := range entries {
+		manifest.Files = append(manifest.Files, entry.file)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error creating archive manifest",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	archiveWriter, err := archive.NewWriter(w, format)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid archive format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	name := reciter + "-" + slug
+	if chapter != 0 {
+		name += "-" + strconv.Itoa(chapter)
+	}
+	w.Header().Set("Content-Type", archive.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+
+	// The response has started, so errors can only abort the archive
+	err = writeRecitationArchive(archiveWriter, uploadsDir, manifest, manifestData)
+	if err != nil {
+		log.Printf("Error writing archive of %s/%s: %v\n", reciter, slug, err)
+		return
+	}
+
+	err = archiveWriter.Close()
+	if err != nil {
+		log.Printf("Error writing archive of %s/%s: %v\n", reciter, slug, err)
+	}
+}
+
+func writeRecitationArchive(archiveWriter archive.Writer, uploadsDir string, manifest models.ArchiveManifest, manifestData []byte) error {
+	err := archiveWriter.Add("manifest.json", int64(len(manifestData)), manifest.CreatedAt, bytes.NewReader(manifestData))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		err = addFileToArchive(context.Background(), archiveWriter, filepath.Join(uploadsDir, file.Audio), file.Audio)
+		if err != nil {
+			return err
+		}
+
+		if file.Timings == "" {
+			continue
+		}
+
+		err = addFileToArchive(context.Background(), archiveWriter, filepath.Join(uploadsDir, file.Timings), file.Timings)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addFileToArchive(ctx context.Context, archiveWriter archive.Writer, path string, name string) error {
+	object, err := storage.Stat(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	file, err := storage.Open(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return archiveWriter.Add(name, object.Size, object.ModTime, file)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// GetRecitationAudio godoc
+//
+//	@Tags		RecitationFile
+//	@Produce	audio/mpeg
+//	@Produce	json
+//
+//	@Param		reciter		path	string	true	"Reciter"
+//	@Param		slug		path	string	true	"Slug"
+//	@Param		verse_key	path	string	true	"Verse key"
+//	@Param		format		path	string	false	"Transcoding profile, or master for the original upload. Negotiated from the Accept header if omitted"
+//
+//	@Success	200
+//	@Failure	404	{object}	models.Error
+//	@Failure	406	{object}	models.Error
+//	@Router		/audio/{reciter}/{slug}/{verse_key} [get]
+//	@Router		/audio/{reciter}/{slug}/{verse_key}/{format} [get]
+func GetRecitationAudio(w http.ResponseWriter, r *http.Request) {
+	reciter := chi.URLParam(r, "reciter")
+	slug := chi.URLParam(r, "slug")
+	verseKey := chi.URLParam(r, "verse_key")
+	format := chi.URLParam(r, "format")
+
+	if format == audio.MasterFormat {
+		masterFilepath := audio.MasterPath(reciter, slug, verseKey)
+		if !fileExists(masterFilepath) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, render.M{
+				"message": "The original upload of this verse was not kept",
+				"error":   "",
+			})
+			return
+		}
+
+		serveUpload(w, r, masterFilepath)
+		return
+	}
+
+	if format != "" {
+		profile, ok := audio.LookupProfile(format)
+		if !ok || !fileExists(profile.Path(reciter, slug, verseKey)) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, render.M{
+				"message": "Audio is not available in this format",
+				"error":   "",
+			})
+			return
+		}
+
+		w.Header().Set("Content-Type", profile.ContentType)
+		serveUpload(w, r, profile.Path(reciter, slug, verseKey))
+		return
+	}
+
+	// Files uploaded before a profile was added only exist in the older
+	// profiles.
+	available := []audio.Profile{}
+	for _, profile := range audio.Profiles() {
+		if fileExists(profile.Path(reciter, slug, verseKey)) {
+			available = append(available, profile)
+		}
+	}
+	if len(available) == 0 {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "Audio of this verse does not exist",
+			"error":   "",
+		})
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	profile, ok := audio.Negotiate(r.Header.Get("Accept"), available)
+	if !ok {
+		render.Status(r, http.StatusNotAcceptable)
+		render.JSON(w, r, render.M{
+			"message": "None of the available formats are acceptable",
+			"error":   "",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", profile.ContentType)
+	serveUpload(w, r, profile.Path(reciter, slug, verseKey))
+}
+
+// fileExists reports whether a file of the uploads is stored.
+func fileExists(path string) bool {
+	return storage.Exists(context.Background(), path)
+}
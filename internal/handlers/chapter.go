@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/chapters"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// GetChapterAudio godoc
+//
+//	@Tags		Chapter
+//	@Produce	audio/mpeg
+//	@Produce	json
+//
+//	@Param		reciter	path	string	true	"Reciter"
+//	@Param		slug	path	string	true	"Slug"
+//	@Param		chapter	path	int		true	"Chapter"
+//	@Param		basmala	query	bool	false	"Prepend the basmala (1:1) to chapters other than 1 and 9"
+//
+//	@Success	200
+//	@Failure	400	{object}	models.Error
+//	@Failure	404	{object}	models.Error
+//	@Failure	500	{object}	models.Error
+//	@Router		/chapter-audio/{reciter}/{slug}/{chapter} [get]
+func GetChapterAudio(w http.ResponseWriter, r *http.Request) {
+	audioFilepath, _, ok := ensureChapter(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/mpeg")
+	serveUpload(w, r, audioFilepath)
+}
+
+// GetChapterTimings godoc
+//
+//	@Tags		Chapter
+//	@Produce	json
+//
+//	@Param		reciter	path		string	true	"Reciter"
+//	@Param		slug	path		string	true	"Slug"
+//	@Param		chapter	path		int		true	"Chapter"
+//	@Param		basmala	query		bool	false	"Prepend the basmala (1:1) to chapters other than 1 and 9"
+//
+//	@Success	200		{object}	models.ChapterTiming
+//	@Failure	400		{object}	models.Error
+//	@Failure	404		{object}	models.Error
+//	@Failure	500		{object}	models.Error
+//	@Router		/chapter-timings/{reciter}/{slug}/{chapter} [get]
+func GetChapterTimings(w http.ResponseWriter, r *http.Request) {
+	_, timingsFilepath, ok := ensureChapter(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	serveUpload(w, r, timingsFilepath)
+}
+
+// ensureChapter generates the chapter in the URL parameters if needed and
+// returns the paths of its audio and timings.
+func ensureChapter(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	chapter, err := strconv.Atoi(chi.URLParam(r, "chapter"))
+	if err == nil && quran.VerseCount(chapter) == 0 {
+		err = errors.New("there is no such chapter")
+	}
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid chapter",
+			"error":   err.Error(),
+		})
+		return "", "", false
+	}
+
+	basmala, _ := strconv.ParseBool(r.URL.Query().Get("basmala"))
+
+	audioFilepath, timingsFilepath, err := chapters.Ensure(chi.URLParam(r, "reciter"), chi.URLParam(r, "slug"), chapter, basmala)
+	if errors.Is(err, chapters.ErrIncomplete) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "Chapter audio is not available",
+			"error":   err.Error(),
+		})
+		return "", "", false
+	}
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error generating chapter audio",
+			"error":   err.Error(),
+		})
+		return "", "", false
+	}
+
+	return audioFilepath, timingsFilepath, true
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type coverageCountsDTO struct {
+	Verses            int     `json:"verses"`
+	Uploaded          int     `json:"uploaded"`
+	WithTimings       int     `json:"with_timings"`
+	PendingLafzize    int     `json:"pending_lafzize"`
+	Percentage        float64 `json:"percentage"`
+	TimingsPercentage float64 `json:"timings_percentage"`
+}
+
+type chapterCoverageDTO struct {
+	Chapter int `json:"chapter"`
+	coverageCountsDTO
+	Missing []string `json:"missing"`
+}
+
+type juzCoverageDTO struct {
+	Juz int `json:"juz"`
+	coverageCountsDTO
+}
+
+type coverageDTO struct {
+	coverageCountsDTO
+	Chapters []chapterCoverageDTO `json:"chapters"`
+	Juzs     []juzCoverageDTO     `json:"juzs"`
+}
+
+// GetRecitationCoverage godoc
+//
+//	@Tags		Recitation
+//	@Produce	json
+//
+//	@Param		reciter	path		string	true	"Reciter"
+//	@Param		slug	path		string	true	"Slug"
+//
+//	@Success	200		{object}	coverageDTO
+//	@Failure	500		{object}	models.Error
+//	@Router		/recitations/{reciter}/{slug}/coverage [get]
+func GetRecitationCoverage(w http.ResponseWriter, r *http.Request) {
+	coverage, err := recitationCoverage(chi.URLParam(r, "reciter"), chi.URLParam(r, "slug"))
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation files",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, coverage)
+}
+
+// recitationCoverage counts the uploaded, timed and pending verses of a
+// recitation per chapter, per juz and overall.
+func recitationCoverage(reciter string, slug string) (coverageDTO, error) {
+	recitationFiles, err := db.Queries.RecitationFileSelectRecitationFiles(context.Background(), sqlc.RecitationFileSelectRecitationFilesParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
+	if err != nil {
+		return coverageDTO{}, err
+	}
+
+	recitationFilesByVerseKey := make(map[string]sqlc.RecitationFile, len(recitationFiles))
+	for _, recitationFile := range recitationFiles {
+		recitationFilesByVerseKey[recitationFile.VerseKey] = recitationFile
+	}
+
+	coverage := coverageDTO{
+		Chapters: make([]chapterCoverageDTO, quran.Chapters),
+		Juzs:     make([]juzCoverageDTO, quran.Juzs),
+	}
+	for i := range coverage.Juzs {
+		coverage.Juzs[i].Juz = i + 1
+	}
+
+	for chapter := 1; chapter <= quran.Chapters; chapter++ {
+		chapterCoverage := &coverage.Chapters[chapter-1]
+		chapterCoverage.Chapter = chapter
+		chapterCoverage.Missing = []string{}
+
+		for verse := 1; verse <= quran.VerseCount(chapter); verse++ {
+			verseKey := quran.VerseKey(chapter, verse)
+			recitationFile, uploaded := recitationFilesByVerseKey[verseKey]
+			if !uploaded {
+				chapterCoverage.Missing = append(chapterCoverage.Missing, verseKey)
+			}
+
+			coverage.add(recitationFile, uploaded)
+			chapterCoverage.add(recitationFile, uploaded)
+			coverage.Juzs[quran.Juz(chapter, verse)-1].add(recitationFile, uploaded)
+		}
+	}
+
+	coverage.computePercentages()
+	for i := range coverage.Chapters {
+		coverage.Chapters[i].computePercentages()
+	}
+	for i := range coverage.Juzs {
+		coverage.Juzs[i].computePercentages()
+	}
+
+	return coverage, nil
+}
+
+func (counts *coverageCountsDTO) add(recitationFile sqlc.RecitationFile, uploaded bool) {
+	counts.Verses++
+	if !uploaded {
+		return
+	}
+
+	counts.Uploaded++
+	if recitationFile.HasTimings {
+		counts.WithTimings++
+	}
+	if recitationFile.LafzizeProcessing {
+		counts.PendingLafzize++
+	}
+}
+
+func (counts *coverageCountsDTO) computePercentages() {
+	if counts.Verses == 0 {
+		return
+	}
+	counts.Percentage = float64(counts.Uploaded) / float64(counts.Verses) * 100
+	counts.TimingsPercentage = float64(counts.WithTimings) / float64(counts.Verses) * 100
+}
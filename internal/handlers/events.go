@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/events"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// Interval between keep-alive comments on idle event streams.
+const heartbeatInterval = 30 * time.Second
+
+// GetRecitationEvents godoc
+//
+//	@Tags		Events
+//	@Produce	text/event-stream
+//
+//	@Param		reciter	path		string	true	"Reciter"
+//	@Param		slug	path		string	true	"Slug"
+//
+//	@Success	200		{object}	events.Event
+//	@Failure	500		{object}	models.Error
+//	@Router		/events/{reciter}/{slug} [get]
+func GetRecitationEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Streaming is not supported",
+			"error":   "",
+		})
+		return
+	}
+
+	channel, unsubscribe := events.Subscribe(chi.URLParam(r, "reciter"), chi.URLParam(r, "slug"))
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event := <-channel:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/archive"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/transcode"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// everyAyahExportDTO summarises an export, as export.json at its root.
+type everyAyahExportDTO struct {
+	Audio   int `json:"audio"`
+	Timings int `json:"timings"`
+	// Skipped lists the verse keys whose audio is not transcoded yet.
+	Skipped []string `json:"skipped"`
+}
+
+// GetEveryAyahFile godoc
+//
+//	@Tags		EveryAyah
+//	@Produce	audio/mpeg
+//	@Produce	json
+//
+//	@Param		reciter	path	string	true	"Reciter"
+//	@Param		slug	path	string	true	"Slug"
+//	@Param		file	path	string	true	"EveryAyah file name, for example 001001.mp3"
+//
+//	@Success	200
+//	@Failure	404	{object}	models.Error
+//	@Router		/everyayah/{reciter}/{slug}/{file} [get]
+func GetEveryAyahFile(w http.ResponseWriter, r *http.Request) {
+	serveEveryAyahFile(w, r, ".mp3")
+}
+
+// GetEveryAyahTimings godoc
+//
+//	@Tags		EveryAyah
+//	@Produce	json
+//
+//	@Param		reciter	path		string	true	"Reciter"
+//	@Param		slug	path		string	true	"Slug"
+//	@Param		file	path		string	true	"EveryAyah file name, for example 001001.json"
+//
+//	@Success	200		{object}	models.Timing
+//	@Failure	404		{object}	models.Error
+//	@Router		/everyayah/{reciter}/{slug}/timings/{file} [get]
+func GetEveryAyahTimings(w http.ResponseWriter, r *http.Request) {
+	serveEveryAyahFile(w, r, ".json")
+}
+
+// ExportEveryAyah godoc
+//
+//	@Tags		EveryAyah
+//	@Produce	application/zip
+//	@Produce	application/x-tar
+//	@Produce	application/gzip
+//
+//	@Param		X-CSRF-TOKEN	header	string	true	"CSRF Token"
+//
+//	@Param		slug			path	string	true	"Slug"
+//	@Param		format			query	string	false	"Archive format"	Enums(zip, tar, tar.gz)	default(zip)
+//
+//	@Success	200
+//	@Failure	400	{object}	models.Error
+//	@Failure	401	{object}	models.Error
+//	@Failure	500	{object}	models.Error
+//	@Router		/everyayah/{slug}/export [post]
+func ExportEveryAyah(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = archive.FormatZip
+	}
+
+	recitationFiles, err := db.Queries.RecitationFileSelectRecitationFiles(context.Background(), sqlc.RecitationFileSelectRecitationFilesParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation files",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	type exportEntry struct {
+		position [2]int
+		name     string
+		verseKey string
+		timings  bool
+	}
+
+	uploadsDir := filepath.Join("data", "uploads", reciter, slug)
+	export := everyAyahExportDTO{Skipped: []string{}}
+	entries := []exportEntry{}
+	for _, recitationFile := range recitationFiles {
+		chapter, verse, err := quran.ParseVerseKey(recitationFile.VerseKey)
+		if err != nil {
+			continue
+		}
+
+		if recitationFile.TranscodeStatus != transcode.StatusDone || !fileExists(filepath.Join(uploadsDir, recitationFile.VerseKey+".mp3")) {
+			export.Skipped = append(export.Skipped, recitationFile.VerseKey)
+			continue
+		}
+
+		entries = append(entries, exportEntry{
+			position: [2]int{chapter, verse},
+			name:     quran.EveryAyahName(chapter, verse),
+			verseKey: recitationFile.VerseKey,
+			timings:  recitationFile.HasTimings,
+		})
+		export.Audio++
+		if recitationFile.HasTimings {
+			export.Timings++
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return comparePositions(entries[i].position, entries[j].position) < 0
+	})
+
+	exportData, err := json.MarshalIndent(export, "", "\t")
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error creating export summary",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	archiveWriter, err := archive.NewWriter(w, format)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid archive format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", archive.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", reciter+"-"+slug+"-everyayah."+format))
+
+	// The response has started, so errors can only abort the archive
+	err = archiveWriter.Add("export.json", int64(len(exportData)), time.Now().UTC(), bytes.NewReader(exportData))
+	for _, entry := range entries {
+		if err != nil {
+			break
+		}
+
+		err = addFileToArchive(context.Background(), archiveWriter, filepath.Join(uploadsDir, entry.verseKey+".mp3"), entry.name+".mp3")
+		if err == nil && entry.timings {
+			err = addFileToArchive(context.Background(), archiveWriter, filepath.Join(uploadsDir, entry.verseKey+".json"), "timings/"+entry.name+".json")
+		}
+	}
+	if err == nil {
+		err = archiveWriter.Close()
+	}
+	if err != nil {
+		log.Printf("Error writing EveryAyah export of %s/%s: %v\n", reciter, slug, err)
+	}
+}
+
+// serveEveryAyahFile serves the upload with the given extension of the verse
+// named by the file URL parameter.
+func serveEveryAyahFile(w http.ResponseWriter, r *http.Request, extension string) {
+	name, found := strings.CutSuffix(chi.URLParam(r, "file"), extension)
+	if !found {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "File not found",
+			"error":   "expected a " + extension + " file",
+		})
+		return
+	}
+
+	verseKey, err := quran.ParseEveryAyahName(name)
+	if err != nil {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "File not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	path := filepath.Join("data", "uploads", chi.URLParam(r, "reciter"), chi.URLParam(r, "slug"), verseKey+extension)
+	if _, err := storage.Stat(context.Background(), path); err != nil {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "File not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	serveUpload(w, r, path)
+}
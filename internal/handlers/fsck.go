@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/fsck"
+	"github.com/go-chi/render"
+)
+
+// GetFsck godoc
+//
+//	@Tags		Admin
+//	@Produce	json
+//
+//	@Success	200	{object}	fsck.Report
+//	@Failure	401	{object}	models.Error
+//	@Failure	403	{object}	models.Error
+//	@Failure	500	{object}	models.Error
+//	@Router		/admin/fsck [get]
+func GetFsck(w http.ResponseWriter, r *http.Request) {
+	runFsck(w, r, false)
+}
+
+// RepairFsck godoc
+//
+//	@Tags		Admin
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string	true	"CSRF Token"
+//
+//	@Success	200				{object}	fsck.Report
+//	@Failure	401				{object}	models.Error
+//	@Failure	403				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/admin/fsck/repair [post]
+func RepairFsck(w http.ResponseWriter, r *http.Request) {
+	runFsck(w, r, true)
+}
+
+func runFsck(w http.ResponseWriter, r *http.Request, repair bool) {
+	report, err := fsck.Check(context.Background(), repair)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error checking storage consistency",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, report)
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/archive"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/revisions"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/transcode"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/unitofwork"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/validators"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/spf13/viper"
+)
+
+const (
+	importImported = "imported"
+	importSkipped  = "skipped"
+	importFailed   = "failed"
+)
+
+// The largest timings file that is imported, as timings are held in memory
+// until all audio has been saved.
+const maxImportTimingsSize = 1 << 20
+
+type importFileDTO struct {
+	Name             string                `json:"name"`
+	VerseKey         string                `json:"verse_key,omitempty"`
+	Kind             string                `json:"kind"`
+	Status           string                `json:"status"`
+	Error            string                `json:"error,omitempty"`
+	ValidationErrors []models.SegmentError `json:"validation_errors,omitempty"`
+}
+
+type importSummaryDTO struct {
+	Recitation        sqlc.Recitation `json:"recitation"`
+	CreatedRecitation bool            `json:"created_recitation"`
+	Imported          int             `json:"imported"`
+	Skipped           int             `json:"skipped"`
+	Failed            int             `json:"failed"`
+	Files             []importFileDTO `json:"files"`
+}
+
+// ImportRecitation godoc
+//
+//	@Tags		Recitation
+//	@Accept		multipart/form-data
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string	true	"CSRF Token"
+//
+//	@Param		slug			path		string	true	"Slug"
+//	@Param		file			formData	file	true	"ZIP or tar archive of {verse_key}.{ext} audio and {verse_key}.json timings"
+//	@Param		format			formData	string	false	"Archive format, detected from the file name by default"	Enums(zip, tar, tar.gz)
+//
+//	@Success	200				{object}	importSummaryDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	401				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/recitations/{slug}/import [post]
+func ImportRecitation(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+
+	r.Body = http.MaxBytesReader(w, r.Body, viper.GetInt64("import_max_size"))
+	err := r.ParseMultipartForm(32 << 20) // Larger archives are buffered on disk
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		render.Status(r, http.StatusRequestEntityTooLarge)
+		render.JSON(w, r, render.M{
+			"message": "Archive is too large",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error parsing multipart form",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error retrieving uploaded file",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue("format")
+	if format == "" {
+		format, err = archive.DetectFormat(header.Filename)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, render.M{
+				"message": "Invalid archive format",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	limits := archive.Limits{
+		Entry: viper.GetInt64("import_max_entry_size"),
+		Total: viper.GetInt64("import_max_total_size"),
+	}
+	err = archive.Check(file, header.Size, format, limits)
+	if errors.Is(err, archive.ErrTooLarge) {
+		render.Status(r, http.StatusRequestEntityTooLarge)
+		render.JSON(w, r, render.M{
+			"message": "Archive is too large",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid archive",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	summary := importSummaryDTO{Files: []importFileDTO{}}
+
+	summary.Recitation, err = db.Queries.RecitationSelectRecitation(context.Background(), sqlc.RecitationSelectRecitationParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		summary.Recitation, err = db.Queries.RecitationCreateRecitation(context.Background(), sqlc.RecitationCreateRecitationParams{
+			Reciter: reciter,
+			Slug:    slug,
+			Name:    slug,
+		})
+		summary.CreatedRecitation = err == nil
+	}
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error creating recitation",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Timings are applied once all audio has been saved, as tar archives can
+	// only be read in order.
+	type pendingTiming struct {
+		result importFileDTO
+		data   []byte
+	}
+	pendingTimings := map[string]pendingTiming{}
+	importedAudio := []string{}
+
+	err = archive.Walk(file, header.Size, format, limits, func(name string, content io.Reader) error {
+		verseKey, extension, ok := importEntryVerseKey(name)
+		if !ok {
+			return nil
+		}
+
+		if extension == ".json" {
+			data, err := io.ReadAll(io.LimitReader(content, maxImportTimingsSize+1))
+			if err != nil {
+				return err
+			}
+			if len(data) > maxImportTimingsSize {
+				summary.Files = append(summary.Files, importFileDTO{
+					Name:     name,
+					VerseKey: verseKey,
+					Kind:     "timings",
+					Status:   importFailed,
+					Error:    fmt.Sprintf("timings are larger than %d bytes", maxImportTimingsSize),
+				})
+				return nil
+			}
+			pendingTimings[verseKey] = pendingTiming{
+				result: importFileDTO{Name: name, VerseKey: verseKey, Kind: "timings"},
+				data:   data,
+			}
+			return nil
+		}
+
+		result := importFileDTO{Name: name, VerseKey: verseKey, Kind: "audio"}
+		result.Status, result.Error = importAudio(reciter, slug, verseKey, content)
+		if result.Status == importImported {
+			importedAudio = append(importedAudio, verseKey)
+		}
+		summary.Files = append(summary.Files, result)
+		return nil
+	})
+	if err != nil {
+		summary.Files = append(summary.Files, importFileDTO{
+			Name:   header.Filename,
+			Kind:   "archive",
+			Status: importFailed,
+			Error:  err.Error(),
+		})
+	}
+
+	verseKeys := []string{}
+	for verseKey := range pendingTimings {
+		verseKeys = append(verseKeys, verseKey)
+	}
+	sort.Strings(verseKeys)
+
+	for _, verseKey := range verseKeys {
+		result := pendingTimings[verseKey].result
+		result.Status, result.Error, result.ValidationErrors = importTiming(reciter, slug, verseKey, pendingTimings[verseKey].data)
+		summary.Files = append(summary.Files, result)
+	}
+
+	// Audio is only queued for transcoding once its timings are in place, so
+	// that it is not lafzized automatically in the meantime.
+	err = enqueueImportedAudio(reciter, slug, importedAudio)
+	if err != nil {
+		log.Printf("Error queueing imported recitation files of %s/%s for transcoding: %v\n", reciter, slug, err)
+	}
+
+	for _, result := range summary.Files {
+		switch result.Status {
+		case importImported:
+			summary.Imported++
+		case importSkipped:
+			summary.Skipped++
+		case importFailed:
+			summary.Failed++
+		}
+	}
+
+	render.JSON(w, r, summary)
+}
+
+// importEntryVerseKey maps an archive entry named {verse_key}.{ext} or in the
+// EveryAyah layout to its verse key. Other entries, such as manifest.json,
+// are ignored.
+func importEntryVerseKey(name string) (string, string, bool) {
+	base := path.Base(name)
+	if strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") {
+		return "", "", false
+	}
+
+	extension := path.Ext(base)
+	stem := strings.TrimSuffix(base, extension)
+
+	if quran.ValidVerseKey(stem) {
+		return stem, extension, true
+	}
+
+	verseKey, err := quran.ParseEveryAyahName(stem)
+	if err != nil {
+		return "", "", false
+	}
+	return verseKey, extension, true
+}
+
+func importAudio(reciter string, slug string, verseKey string, content io.Reader) (string, string) {
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		return importFailed, err.Error()
+	}
+	defer unit.Rollback()
+
+	_, err = unit.Queries.RecitationFileCreateRecitationFile(context.Background(), sqlc.RecitationFileCreateRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		return importSkipped, fmt.Sprintf("error creating recitation file, it may already exist: %v", err)
+	}
+
+	err = stageUpload(unit, reciter, slug, verseKey, content)
+	if err != nil {
+		return importFailed, err.Error()
+	}
+
+	err = unit.Commit()
+	if err != nil {
+		return importFailed, err.Error()
+	}
+
+	return importImported, ""
+}
+
+func enqueueImportedAudio(reciter string, slug string, verseKeys []string) error {
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer unit.Rollback()
+
+	for _, verseKey := range verseKeys {
+		err = transcode.Enqueue(context.Background(), unit, reciter, slug, verseKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return unit.Commit()
+}
+
+func importTiming(reciter string, slug string, verseKey string, data []byte) (string, string, []models.SegmentError) {
+	recitationFile, err := db.Queries.RecitationFileSelectRecitationFile(context.Background(), sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		return importFailed, fmt.Sprintf("no audio for %s: %v", verseKey, err), nil
+	}
+	if recitationFile.LafzizeProcessing {
+		return importSkipped, "the recitation is currently being lafzized", nil
+	}
+
+	var timing models.Timing
+	err = json.Unmarshal(data, &timing)
+	if err != nil {
+		return importFailed, fmt.Sprintf("error parsing timings: %v", err), nil
+	}
+
+	validationErrors := validators.ValidateTiming(verseKey, timing, recitationFileDuration(recitationFile))
+	if len(validationErrors) > 0 {
+		return importFailed, fmt.Sprintf("%d validation errors", len(validationErrors)), validationErrors
+	}
+
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		return importFailed, err.Error(), nil
+	}
+	defer unit.Rollback()
+
+	err = saveRecitationTiming(unit, reciter, slug, verseKey, timing)
+	if err != nil {
+		return importFailed, err.Error(), nil
+	}
+
+	_, err = revisions.Record(context.Background(), unit.Queries, reciter, slug, verseKey, reciter, revisions.SourceImport, timing)
+	if err != nil {
+		return importFailed, fmt.Sprintf("error recording timing revision: %v", err), nil
+	}
+
+	err = unit.Commit()
+	if err != nil {
+		return importFailed, err.Error(), nil
+	}
+
+	return importImported, "", nil
+}
@@ -1,23 +1,49 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/lafzize"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/transcode"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/validators"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
-	"github.com/spf13/viper"
 )
 
+type lafzizeRecitationDTO struct {
+	Chapter     int    `json:"chapter" validate:"min=0,max=114"`
+	From        string `json:"from" validate:"omitempty,verse_key"`
+	To          string `json:"to" validate:"omitempty,verse_key"`
+	OnlyMissing bool   `json:"only_missing"`
+}
+
+type lafzizeBatchDTO struct {
+	Batch     sqlc.LafzizeBatch `json:"batch"`
+	Total     int64             `json:"total"`
+	Queued    int64             `json:"queued"`
+	Running   int64             `json:"running"`
+	Succeeded int64             `json:"succeeded"`
+	Failed    int64             `json:"failed"`
+	Cancelled int64             `json:"cancelled"`
+	// Skipped lists the verse keys that were not scheduled because their audio
+	// is not transcoded yet or they are already being lafzized.
+	Skipped []string `json:"skipped,omitempty"`
+	// Errors maps the verse keys that could not be scheduled to the reason.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
 // Lafzize godoc
 //
 //	@Tags		lafzize
@@ -27,7 +53,7 @@ import (
 //
 //	@Param		slug			path		string	true	"Recitation slug"
 //	@Param		verse_key		path		string	true	"Verse key of recitation"
-//	@Success	200				{object}	sqlc.RecitationFileUpdateRecitationFileParams
+//	@Success	200				{object}	sqlc.LafzizeJob
 //	@Failure	400				{object}	models.Error
 //	@Failure	401				{object}	models.Error
 //	@Router		/lafzize/{slug}/{verse_key} [post]
@@ -36,159 +62,374 @@ func Lafzize(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 	verseKey := chi.URLParam(r, "verse_key")
 
-	existingRecitationFile, err := db.Queries.RecitationFileSelectRecitationFile(context.Background(), sqlc.RecitationFileSelectRecitationFileParams{
-		Reciter:  reciter,
-		Slug:     slug,
-		VerseKey: verseKey,
-	})
+	job, err := lafzize.Schedule(context.Background(), reciter, slug, verseKey, sql.NullInt64{})
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error checking status of recitation",
+			"message": "Error scheduling lafzize job",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	if existingRecitationFile.LafzizeProcessing {
+	render.JSON(w, r, job)
+}
+
+// CancelLafzize godoc
+//
+//	@Tags		lafzize
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string	true	"CSRF Token"
+//
+//	@Param		slug			path		string	true	"Recitation slug"
+//	@Param		verse_key		path		string	true	"Verse key of recitation"
+//	@Success	200				{object}	sqlc.LafzizeJob
+//	@Failure	400				{object}	models.Error
+//	@Failure	401				{object}	models.Error
+//	@Router		/lafzize/{slug}/{verse_key} [delete]
+func CancelLafzize(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+	verseKey := chi.URLParam(r, "verse_key")
+
+	job, err := lafzize.Cancel(context.Background(), reciter, slug, verseKey)
+	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "The recitation is already being lafzized",
-			"error":   "",
+			"message": "Error cancelling lafzize job",
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	updatedRecitationFile, err := db.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
-		Reciter:           reciter,
-		Slug:              slug,
-		VerseKey:          verseKey,
-		HasTimings:        false,
-		LafzizeProcessing: true,
+	render.JSON(w, r, job)
+}
+
+// GetLafzizeJob godoc
+//
+//	@Tags		lafzize
+//	@Produce	json
+//
+//	@Param		reciter		path		string	true	"Reciter"
+//	@Param		slug		path		string	true	"Recitation slug"
+//	@Param		verse_key	path		string	true	"Verse key of recitation"
+//	@Success	200			{object}	sqlc.LafzizeJob
+//	@Failure	400			{object}	models.Error
+//	@Router		/lafzize/{reciter}/{slug}/{verse_key} [get]
+func GetLafzizeJob(w http.ResponseWriter, r *http.Request) {
+	job, err := db.Queries.LafzizeJobSelectLatestLafzizeJob(context.Background(), sqlc.LafzizeJobSelectLatestLafzizeJobParams{
+		Reciter:  chi.URLParam(r, "reciter"),
+		Slug:     chi.URLParam(r, "slug"),
+		VerseKey: chi.URLParam(r, "verse_key"),
 	})
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error updating status of recitation file",
+			"message": "Error querying lafzize job",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	audioPath := filepath.Join("data", "uploads", reciter, slug, fmt.Sprintf("%s.mp3", verseKey))
-	timingsPath := filepath.Join("data", "uploads", reciter, slug, fmt.Sprintf("%s.json", verseKey))
+	render.JSON(w, r, job)
+}
 
-	file, err := os.Open(audioPath)
-	if err != nil {
+// LafzizeRecitation godoc
+//
+//	@Tags		lafzize
+//	@Accept		json
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string					true	"CSRF Token"
+//
+//	@Param		slug			path		string					true	"Recitation slug"
+//	@Param		request			body		lafzizeRecitationDTO	false	"Filters"
+//	@Success	200				{object}	lafzizeBatchDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	401				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/lafzize/{slug} [post]
+func LafzizeRecitation(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+
+	var request lafzizeRecitationDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Recitation file does not exist.",
+			"message": "Could not parse request body",
 			"error":   err.Error(),
 		})
 		return
 	}
-	defer file.Close()
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
 
-	part, err := writer.CreateFormFile("file", filepath.Base(file.Name()))
+	err = validators.ValidateStruct(request)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error making request to lafzize server",
+			"message": "Invalid request body",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	_, err = io.Copy(part, file)
+	from, to, err := parseVerseKeyRange(request.Chapter, request.From, request.To)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error making request to lafzize server",
+			"message": "Invalid verse key range",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	err = writer.WriteField("verse_key", verseKey)
+	recitationFiles, err := db.Queries.RecitationFileSelectRecitationFiles(context.Background(), sqlc.RecitationFileSelectRecitationFilesParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
-			"message": "Error making request to lafzize server",
+			"message": "Error querying recitation files",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	err = writer.Close()
-	if err != nil {
+	skipped := []string{}
+	verseKeys := []string{}
+	for _, recitationFile := range recitationFiles {
+		if request.OnlyMissing && recitationFile.HasTimings {
+			continue
+		}
+
+		chapter, verse, err := quran.ParseVerseKey(recitationFile.VerseKey)
+		if err != nil {
+			continue
+		}
+		if request.Chapter != 0 && chapter != request.Chapter {
+			continue
+		}
+		position := [2]int{chapter, verse}
+		if comparePositions(position, from) < 0 || comparePositions(position, to) > 0 {
+			continue
+		}
+
+		if recitationFile.TranscodeStatus != transcode.StatusDone {
+			skipped = append(skipped, recitationFile.VerseKey)
+			continue
+		}
+		verseKeys = append(verseKeys, recitationFile.VerseKey)
+	}
+
+	if len(verseKeys) == 0 {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error making request to lafzize server",
-			"error":   err.Error(),
+			"message": "No recitation files to lafzize",
+			"error":   noneScheduledError(skipped, nil).Error(),
 		})
 		return
 	}
 
-	lafzizeRequest, err := http.NewRequest("POST", viper.GetString("lafzize_endpoint"), body)
+	batch, err := db.Queries.LafzizeBatchCreateLafzizeBatch(context.Background(), sqlc.LafzizeBatchCreateLafzizeBatchParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
-			"message": "Error making request to lafzize server",
+			"message": "Error creating lafzize batch",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	lafzizeRequest.Header.Add("Content-Type", writer.FormDataContentType())
-	client := &http.Client{}
-
-	err = os.RemoveAll(timingsPath)
-	if err != nil {
-		log.Printf("Error removing possible existing timing file: %v\n", err)
+	scheduled := 0
+	failures := map[string]string{}
+	for _, verseKey := range verseKeys {
+		_, err = lafzize.Schedule(context.Background(), reciter, slug, verseKey, sql.NullInt64{Int64: batch.ID, Valid: true})
+		if errors.Is(err, lafzize.ErrProcessing) {
+			skipped = append(skipped, verseKey)
+			continue
+		}
+		if err != nil {
+			failures[verseKey] = err.Error()
+			continue
+		}
+		scheduled++
 	}
 
-	go doAsyncRequest(client, lafzizeRequest, timingsPath, updatedRecitationFile)
+	// A batch without jobs would report an empty success.
+	if scheduled == 0 {
+		err = db.Queries.LafzizeBatchDeleteLafzizeBatch(context.Background(), batch.ID)
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, render.M{
+				"message": "Error deleting empty lafzize batch",
+				"error":   err.Error(),
+			})
+			return
+		}
 
-	render.JSON(w, r, updatedRecitationFile)
-}
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "No recitation files were scheduled",
+			"error":   noneScheduledError(skipped, failures).Error(),
+		})
+		return
+	}
 
-func doAsyncRequest(client *http.Client, r *http.Request, timingsPath string, recitationFile sqlc.RecitationFile) {
-	resp, err := client.Do(r)
+	progress, err := batchProgress(batch)
 	if err != nil {
-		log.Printf("Error making request while lafzizing recitation %v: %v\n", timingsPath, err)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying lafzize batch progress",
+			"error":   err.Error(),
+		})
 		return
 	}
 
-	file, err := os.Create(timingsPath)
+	progress.Skipped = skipped
+	progress.Errors = failures
+	render.JSON(w, r, progress)
+}
+
+// GetLafzizeBatch godoc
+//
+//	@Tags		lafzize
+//	@Produce	json
+//
+//	@Param		id	path		int	true	"Batch ID"
+//	@Success	200	{object}	lafzizeBatchDTO
+//	@Failure	400	{object}	models.Error
+//	@Failure	500	{object}	models.Error
+//	@Router		/lafzize-batches/{id} [get]
+func GetLafzizeBatch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		log.Printf("Error creating timings file while lafzizing recitation %v: %v\n", timingsPath, err)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid batch id",
+			"error":   err.Error(),
+		})
 		return
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
+	batch, err := db.Queries.LafzizeBatchSelectLafzizeBatch(context.Background(), id)
 	if err != nil {
-		log.Printf("Error writing to timings file while lafzizing recitation %v: %v\n", timingsPath, err)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error querying lafzize batch",
+			"error":   err.Error(),
+		})
 		return
 	}
-	err = resp.Body.Close()
+
+	progress, err := batchProgress(batch)
 	if err != nil {
-		log.Printf("Error closing reader while lafzizing recitation %v: %v\n", timingsPath, err)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying lafzize batch progress",
+			"error":   err.Error(),
+		})
 		return
 	}
 
-	_, err = db.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
-		Reciter:           recitationFile.Reciter,
-		Slug:              recitationFile.Slug,
-		VerseKey:          recitationFile.VerseKey,
-		HasTimings:        true,
-		LafzizeProcessing: false,
-	})
+	render.JSON(w, r, progress)
+}
+
+func batchProgress(batch sqlc.LafzizeBatch) (lafzizeBatchDTO, error) {
+	progress := lafzizeBatchDTO{Batch: batch}
+
+	counts, err := db.Queries.LafzizeBatchCountLafzizeJobs(context.Background(), sql.NullInt64{Int64: batch.ID, Valid: true})
 	if err != nil {
-		log.Printf("Error updating status while lafzizing recitation %v: %v\n", timingsPath, err)
-		return
+		return progress, err
+	}
+
+	for _, count := range counts {
+		progress.Total += count.Count
+		switch count.State {
+		case lafzize.StateQueued:
+			progress.Queued = count.Count
+		case lafzize.StateRunning:
+			progress.Running = count.Count
+		case lafzize.StateSucceeded:
+			progress.Succeeded = count.Count
+		case lafzize.StateFailed:
+			progress.Failed = count.Count
+		case lafzize.StateCancelled:
+			progress.Cancelled = count.Count
+		}
+	}
+
+	return progress, nil
+}
+
+// parseVerseKeyRange parses optional bounds of a verse key range, defaulting
+// to the whole Qur'an. The bounds must be in order, and in the given chapter
+// unless it is 0.
+func parseVerseKeyRange(chapter int, from string, to string) ([2]int, [2]int, error) {
+	start := [2]int{0, 0}
+	end := [2]int{1 << 30, 1 << 30}
+
+	if from != "" {
+		fromChapter, verse, err := quran.ParseVerseKey(from)
+		if err != nil {
+			return start, end, err
+		}
+		if chapter != 0 && fromChapter != chapter {
+			return start, end, fmt.Errorf("from %s is not in chapter %d", from, chapter)
+		}
+		start = [2]int{fromChapter, verse}
+	}
+
+	if to != "" {
+		toChapter, verse, err := quran.ParseVerseKey(to)
+		if err != nil {
+			return start, end, err
+		}
+		if chapter != 0 && toChapter != chapter {
+			return start, end, fmt.Errorf("to %s is not in chapter %d", to, chapter)
+		}
+		end = [2]int{toChapter, verse}
+	}
+
+	if comparePositions(start, end) > 0 {
+		return start, end, fmt.Errorf("from %s is after to %s", from, to)
+	}
+
+	return start, end, nil
+}
+
+// noneScheduledError explains why no recitation file of a batch was
+// scheduled.
+func noneScheduledError(skipped []string, failures map[string]string) error {
+	if len(skipped) == 0 && len(failures) == 0 {
+		return errors.New("no transcoded recitation files match the filters")
+	}
+
+	reasons := []string{}
+	if len(skipped) > 0 {
+		reasons = append(reasons, fmt.Sprintf("skipped %s, as they are not transcoded yet or already being lafzized", strings.Join(skipped, ", ")))
+	}
+
+	verseKeys := []string{}
+	for verseKey := range failures {
+		verseKeys = append(verseKeys, verseKey)
+	}
+	sort.Strings(verseKeys)
+	for _, verseKey := range verseKeys {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", verseKey, failures[verseKey]))
+	}
+
+	return errors.New(strings.Join(reasons, "; "))
+}
+
+func comparePositions(a [2]int, b [2]int) int {
+	if a[0] != b[0] {
+		return a[0] - b[0]
 	}
+	return a[1] - b[1]
 }
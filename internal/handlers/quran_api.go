@@ -0,0 +1,604 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/chapters"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// The handlers in this file emulate the audio endpoints of the quran.com API
+// (https://api-docs.quran.com/docs/category/quran.com-api), identifying
+// recitations by the ids in recitation_ids.
+
+type quranTranslatedNameDTO struct {
+	Name         string `json:"name"`
+	LanguageName string `json:"language_name"`
+}
+
+type quranRecitationDTO struct {
+	ID             int64                  `json:"id"`
+	ReciterName    string                 `json:"reciter_name"`
+	Style          *string                `json:"style"`
+	TranslatedName quranTranslatedNameDTO `json:"translated_name"`
+}
+
+type quranRecitationsDTO struct {
+	Recitations []quranRecitationDTO `json:"recitations"`
+}
+
+type quranAudioFileDTO struct {
+	VerseKey string     `json:"verse_key"`
+	URL      string     `json:"url"`
+	Segments [][3]int64 `json:"segments"`
+}
+
+type quranPaginationDTO struct {
+	PerPage      int  `json:"per_page"`
+	CurrentPage  int  `json:"current_page"`
+	NextPage     *int `json:"next_page"`
+	TotalPages   int  `json:"total_pages"`
+	TotalRecords int  `json:"total_records"`
+}
+
+type quranAudioFilesDTO struct {
+	AudioFiles []quranAudioFileDTO `json:"audio_files"`
+	Pagination quranPaginationDTO  `json:"pagination"`
+}
+
+type quranChapterAudioFileDTO struct {
+	ID        int64  `json:"id"`
+	ChapterID int    `json:"chapter_id"`
+	FileSize  int64  `json:"file_size"`
+	Format    string `json:"format"`
+	AudioURL  string `json:"audio_url"`
+}
+
+type quranChapterAudioFilesDTO struct {
+	AudioFiles []quranChapterAudioFileDTO `json:"audio_files"`
+}
+
+type quranChapterAudioFileResponseDTO struct {
+	AudioFile quranChapterAudioFileDTO `json:"audio_file"`
+}
+
+// GetQuranRecitations godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Success	200	{object}	quranRecitationsDTO
+//	@Failure	500	{object}	models.Error
+//	@Router		/api/v4/resources/recitations [get]
+func GetQuranRecitations(w http.ResponseWriter, r *http.Request) {
+	recitations, err := db.Queries.RecitationIDSelectRecitations(context.Background())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitations",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response := quranRecitationsDTO{Recitations: []quranRecitationDTO{}}
+	for _, recitation := range recitations {
+		response.Recitations = append(response.Recitations, newQuranRecitationDTO(sqlc.RecitationIDSelectRecitationRow(recitation)))
+	}
+
+	render.JSON(w, r, response)
+}
+
+// GetQuranAudioFilesByChapter godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		recitation_id	path		int	true	"Recitation ID"
+//	@Param		chapter_number	path		int	true	"Chapter number"
+//	@Param		page			query		int	false	"Page"
+//	@Param		per_page		query		int	false	"Records per page"
+//
+//	@Success	200				{object}	quranAudioFilesDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	404				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/api/v4/recitations/{recitation_id}/by_chapter/{chapter_number} [get]
+func GetQuranAudioFilesByChapter(w http.ResponseWriter, r *http.Request) {
+	chapter, ok := parseQuranNumber(w, r, "chapter_number", quran.Chapters)
+	if !ok {
+		return
+	}
+
+	renderQuranAudioFiles(w, r, func(verseChapter int, verse int, info quran.Verse) bool {
+		return verseChapter == chapter
+	})
+}
+
+// GetQuranAudioFilesByJuz godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		recitation_id	path		int	true	"Recitation ID"
+//	@Param		juz_number		path		int	true	"Juz number"
+//	@Param		page			query		int	false	"Page"
+//	@Param		per_page		query		int	false	"Records per page"
+//
+//	@Success	200				{object}	quranAudioFilesDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	404				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/api/v4/recitations/{recitation_id}/by_juz/{juz_number} [get]
+func GetQuranAudioFilesByJuz(w http.ResponseWriter, r *http.Request) {
+	juz, ok := parseQuranNumber(w, r, "juz_number", quran.Juzs)
+	if !ok {
+		return
+	}
+
+	renderQuranAudioFiles(w, r, func(chapter int, verse int, info quran.Verse) bool {
+		return info.JuzNumber == juz
+	})
+}
+
+// GetQuranAudioFilesByHizb godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		recitation_id	path		int	true	"Recitation ID"
+//	@Param		hizb_number		path		int	true	"Hizb number"
+//	@Param		page			query		int	false	"Page"
+//	@Param		per_page		query		int	false	"Records per page"
+//
+//	@Success	200				{object}	quranAudioFilesDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	404				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/api/v4/recitations/{recitation_id}/by_hizb/{hizb_number} [get]
+func GetQuranAudioFilesByHizb(w http.ResponseWriter, r *http.Request) {
+	hizb, ok := parseQuranNumber(w, r, "hizb_number", quran.Hizbs)
+	if !ok {
+		return
+	}
+
+	renderQuranAudioFiles(w, r, func(chapter int, verse int, info quran.Verse) bool {
+		return info.HizbNumber == hizb
+	})
+}
+
+// GetQuranAudioFilesByRub godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		recitation_id	path		int	true	"Recitation ID"
+//	@Param		rub_el_hizb_number	path	int	true	"Rub el hizb number"
+//	@Param		page			query		int	false	"Page"
+//	@Param		per_page		query		int	false	"Records per page"
+//
+//	@Success	200				{object}	quranAudioFilesDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	404				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/api/v4/recitations/{recitation_id}/by_rub/{rub_el_hizb_number} [get]
+func GetQuranAudioFilesByRub(w http.ResponseWriter, r *http.Request) {
+	rub, ok := parseQuranNumber(w, r, "rub_el_hizb_number", quran.RubElHizbs)
+	if !ok {
+		return
+	}
+
+	renderQuranAudioFiles(w, r, func(chapter int, verse int, info quran.Verse) bool {
+		return info.RubElHizbNumber == rub
+	})
+}
+
+// GetQuranAudioFilesByPage godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		recitation_id	path		int	true	"Recitation ID"
+//	@Param		page_number		path		int	true	"Mushaf page number"
+//	@Param		page			query		int	false	"Page"
+//	@Param		per_page		query		int	false	"Records per page"
+//
+//	@Success	200				{object}	quranAudioFilesDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	404				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/api/v4/recitations/{recitation_id}/by_page/{page_number} [get]
+func GetQuranAudioFilesByPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := parseQuranNumber(w, r, "page_number", quran.Pages)
+	if !ok {
+		return
+	}
+
+	renderQuranAudioFiles(w, r, func(chapter int, verse int, info quran.Verse) bool {
+		return info.PageNumber == page
+	})
+}
+
+// GetQuranAudioFilesByAyah godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		recitation_id	path		int		true	"Recitation ID"
+//	@Param		ayah_key		path		string	true	"Verse key"
+//	@Param		page			query		int		false	"Page"
+//	@Param		per_page		query		int		false	"Records per page"
+//
+//	@Success	200				{object}	quranAudioFilesDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	404				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/api/v4/recitations/{recitation_id}/by_ayah/{ayah_key} [get]
+func GetQuranAudioFilesByAyah(w http.ResponseWriter, r *http.Request) {
+	ayahChapter, ayahVerse, err := quran.ParseVerseKey(chi.URLParam(r, "ayah_key"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid verse key",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	renderQuranAudioFiles(w, r, func(chapter int, verse int, info quran.Verse) bool {
+		return chapter == ayahChapter && verse == ayahVerse
+	})
+}
+
+// GetQuranChapterAudioFiles godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		id	path		int	true	"Recitation ID"
+//
+//	@Success	200	{object}	quranChapterAudioFilesDTO
+//	@Failure	400	{object}	models.Error
+//	@Failure	404	{object}	models.Error
+//	@Router		/api/v4/chapter_recitations/{id} [get]
+func GetQuranChapterAudioFiles(w http.ResponseWriter, r *http.Request) {
+	recitation, ok := selectQuranRecitation(w, r, "id")
+	if !ok {
+		return
+	}
+
+	basmala, _ := strconv.ParseBool(r.URL.Query().Get("basmala"))
+
+	// Only chapters that are already generated are listed, while the rest are
+	// generated in the background rather than holding up the response.
+	response := quranChapterAudioFilesDTO{AudioFiles: []quranChapterAudioFileDTO{}}
+	for chapter := 1; chapter <= quran.Chapters; chapter++ {
+		audioFilepath, _, err := chapters.Lookup(recitation.Reciter, recitation.Slug, chapter, basmala)
+		if errors.Is(err, chapters.ErrNotGenerated) {
+			chapters.EnsureLater(recitation.Reciter, recitation.Slug, chapter, basmala)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		audioFile, err := newQuranChapterAudioFileDTO(r, recitation, chapter, audioFilepath)
+		if err != nil {
+			continue
+		}
+		response.AudioFiles = append(response.AudioFiles, audioFile)
+	}
+
+	render.JSON(w, r, response)
+}
+
+// GetQuranChapterAudioFile godoc
+//
+//	@Tags		QuranAPI
+//	@Produce	json
+//
+//	@Param		id				path		int	true	"Recitation ID"
+//	@Param		chapter_number	path		int	true	"Chapter number"
+//
+//	@Success	200				{object}	quranChapterAudioFileResponseDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	404				{object}	models.Error
+//	@Router		/api/v4/chapter_recitations/{id}/{chapter_number} [get]
+func GetQuranChapterAudioFile(w http.ResponseWriter, r *http.Request) {
+	chapter, ok := parseQuranNumber(w, r, "chapter_number", quran.Chapters)
+	if !ok {
+		return
+	}
+
+	recitation, ok := selectQuranRecitation(w, r, "id")
+	if !ok {
+		return
+	}
+
+	basmala, _ := strconv.ParseBool(r.URL.Query().Get("basmala"))
+
+	audioFilepath, _, err := chapters.Ensure(recitation.Reciter, recitation.Slug, chapter, basmala)
+	if err != nil {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "Chapter audio is not available",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	audioFile, err := newQuranChapterAudioFileDTO(r, recitation, chapter, audioFilepath)
+	if err != nil {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "Chapter audio is not available",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, quranChapterAudioFileResponseDTO{AudioFile: audioFile})
+}
+
+// renderQuranAudioFiles renders a page of the verse audio files of the
+// recitation in the recitation_id URL parameter which match a filter.
+func renderQuranAudioFiles(w http.ResponseWriter, r *http.Request, filter func(chapter int, verse int, info quran.Verse) bool) {
+	page, perPage, err := parseQuranPagination(r)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid pagination",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recitation, ok := selectQuranRecitation(w, r, "recitation_id")
+	if !ok {
+		return
+	}
+
+	recitationFiles, err := db.Queries.RecitationFileSelectRecitationFiles(context.Background(), sqlc.RecitationFileSelectRecitationFilesParams{
+		Reciter: recitation.Reciter,
+		Slug:    recitation.Slug,
+	})
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation files",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	type position struct {
+		chapter int
+		verse   int
+		file    sqlc.RecitationFile
+	}
+
+	matching := []position{}
+	for _, recitationFile := range recitationFiles {
+		chapter, verse, err := quran.ParseVerseKey(recitationFile.VerseKey)
+		if err != nil {
+			continue
+		}
+		info, err := quran.VerseInfo(recitationFile.VerseKey)
+		if err != nil || !filter(chapter, verse, info) {
+			continue
+		}
+		matching = append(matching, position{chapter: chapter, verse: verse, file: recitationFile})
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		return comparePositions([2]int{matching[i].chapter, matching[i].verse}, [2]int{matching[j].chapter, matching[j].verse}) < 0
+	})
+
+	pagination := quranPaginationDTO{
+		PerPage:      perPage,
+		CurrentPage:  page,
+		TotalPages:   (len(matching) + perPage - 1) / perPage,
+		TotalRecords: len(matching),
+	}
+	if page < pagination.TotalPages {
+		nextPage := page + 1
+		pagination.NextPage = &nextPage
+	}
+
+	start := min((page-1)*perPage, len(matching))
+	end := min(start+perPage, len(matching))
+
+	response := quranAudioFilesDTO{
+		AudioFiles: []quranAudioFileDTO{},
+		Pagination: pagination,
+	}
+	for _, match := range matching[start:end] {
+		audioFile, err := newQuranAudioFileDTO(r, match.file)
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, render.M{
+				"message": fmt.Sprintf("Error reading timings of %s", match.file.VerseKey),
+				"error":   err.Error(),
+			})
+			return
+		}
+		response.AudioFiles = append(response.AudioFiles, audioFile)
+	}
+
+	render.JSON(w, r, response)
+}
+
+func newQuranRecitationDTO(recitation sqlc.RecitationIDSelectRecitationRow) quranRecitationDTO {
+	reciterName := recitation.Displayname
+	if reciterName == "" {
+		reciterName = recitation.Reciter
+	}
+
+	return quranRecitationDTO{
+		ID:          recitation.ID,
+		ReciterName: reciterName,
+		TranslatedName: quranTranslatedNameDTO{
+			Name:         fmt.Sprintf("%s (%s)", reciterName, recitation.Name),
+			LanguageName: "english",
+		},
+	}
+}
+
+// newQuranAudioFileDTO converts the timings of a recitation file, if any, to
+// quran.com segments of the form [word_index, start_ms, end_ms], where word
+// indices start from 1.
+func newQuranAudioFileDTO(r *http.Request, recitationFile sqlc.RecitationFile) (quranAudioFileDTO, error) {
+	audioFile := quranAudioFileDTO{
+		VerseKey: recitationFile.VerseKey,
+		URL:      publicURL(r, "uploads", recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey+".mp3"),
+		Segments: [][3]int64{},
+	}
+
+	if !recitationFile.HasTimings {
+		return audioFile, nil
+	}
+
+	data, err := storage.ReadFile(context.Background(), filepath.Join("data", "uploads", recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return audioFile, nil
+	}
+	if err != nil {
+		return audioFile, err
+	}
+
+	var timing models.Timing
+	err = json.Unmarshal(data, &timing)
+	if err != nil {
+		return audioFile, err
+	}
+
+	for i, segment := range timing.Segments {
+		audioFile.Segments = append(audioFile.Segments, [3]int64{
+			int64(i + 1),
+			int64(math.Round(segment.Start * 1000)),
+			int64(math.Round(segment.End * 1000)),
+		})
+	}
+
+	return audioFile, nil
+}
+
+// newQuranChapterAudioFileDTO describes the generated audio of a whole
+// chapter.
+func newQuranChapterAudioFileDTO(r *http.Request, recitation sqlc.RecitationIDSelectRecitationRow, chapter int, audioFilepath string) (quranChapterAudioFileDTO, error) {
+	basmala, _ := strconv.ParseBool(r.URL.Query().Get("basmala"))
+
+	audioObject, err := storage.Stat(context.Background(), audioFilepath)
+	if err != nil {
+		return quranChapterAudioFileDTO{}, err
+	}
+
+	audioURL := publicURL(r, "chapter-audio", recitation.Reciter, recitation.Slug, strconv.Itoa(chapter))
+	if basmala {
+		audioURL += "?basmala=true"
+	}
+
+	return quranChapterAudioFileDTO{
+		ID:        recitation.ID*1000 + int64(chapter),
+		ChapterID: chapter,
+		FileSize:  audioObject.Size,
+		Format:    "mp3",
+		AudioURL:  audioURL,
+	}, nil
+}
+
+func selectQuranRecitation(w http.ResponseWriter, r *http.Request, param string) (sqlc.RecitationIDSelectRecitationRow, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid recitation id",
+			"error":   err.Error(),
+		})
+		return sqlc.RecitationIDSelectRecitationRow{}, false
+	}
+
+	recitation, err := db.Queries.RecitationIDSelectRecitation(context.Background(), id)
+	if err != nil {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation",
+			"error":   err.Error(),
+		})
+		return recitation, false
+	}
+
+	return recitation, true
+}
+
+// parseQuranNumber parses a URL parameter which must lie between 1 and max.
+func parseQuranNumber(w http.ResponseWriter, r *http.Request, param string, max int) (int, bool) {
+	number, err := strconv.Atoi(chi.URLParam(r, param))
+	if err == nil && (number < 1 || number > max) {
+		err = fmt.Errorf("%s must be between 1 and %d", param, max)
+	}
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": fmt.Sprintf("Invalid %s", param),
+			"error":   err.Error(),
+		})
+		return 0, false
+	}
+
+	return number, true
+}
+
+// parseQuranPagination parses the page and per_page query parameters,
+// defaulting to the first 10 records like quran.com.
+func parseQuranPagination(r *http.Request) (int, int, error) {
+	page := 1
+	perPage := 10
+
+	var err error
+	if value := r.URL.Query().Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("invalid page %q", value)
+		}
+	}
+
+	if value := r.URL.Query().Get("per_page"); value != "" {
+		perPage, err = strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > 50 {
+			return 0, 0, fmt.Errorf("per_page must be between 1 and 50")
+		}
+	}
+
+	return page, perPage, nil
+}
+
+// publicURL builds an absolute URL on this server from escaped path segments.
+func publicURL(r *http.Request, segments ...string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwardedProto := r.Header.Get("X-Forwarded-Proto"); forwardedProto != "" {
+		scheme = forwardedProto
+	}
+
+	path, _ := url.JoinPath("/", segments...)
+	return (&url.URL{Scheme: scheme, Host: r.Host, Path: path}).String()
+}
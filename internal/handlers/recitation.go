@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"os"
@@ -9,6 +10,7 @@ import (
 
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/unitofwork"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 )
@@ -17,8 +19,15 @@ type createRecitationDTO struct {
 	Slug string `json:"slug"`
 }
 
+type recitationDTO struct {
+	sqlc.Recitation
+	Coverage        float64 `json:"coverage"`
+	TimingsCoverage float64 `json:"timings_coverage"`
+}
+
 type updateRecitationDTO struct {
-	Slug string `json:"slug"`
+	Name        string `json:"name"`
+	AutoLafzize *bool  `json:"auto_lafzize"`
 }
 
 // CreateRecitation godoc
@@ -106,7 +115,7 @@ func GetRecitations(w http.ResponseWriter, r *http.Request) {
 //	@Param		reciter	path		string	true	"Reciter"
 //	@Param		slug	path		string	true	"Slug"
 //
-//	@Success	200		{object}	sqlc.Recitation
+//	@Success	200		{object}	recitationDTO
 //	@Failure	500		{object}	models.Error
 //	@Router		/recitations/{reciter}/{slug} [get]
 func GetRecitation(w http.ResponseWriter, r *http.Request) {
@@ -124,7 +133,21 @@ func GetRecitation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	render.JSON(w, r, recitation)
+	coverage, err := recitationCoverage(recitation.Reciter, recitation.Slug)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation files",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, recitationDTO{
+		Recitation:      recitation,
+		Coverage:        coverage.Percentage,
+		TimingsCoverage: coverage.TimingsPercentage,
+	})
 }
 
 // UpdateRecitation godoc
@@ -163,12 +186,13 @@ func UpdateRecitation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	updatedRecitationData := &sqlc.RecitationUpdateRecitationParams{
-		Reciter: reciter,
-		Slug:    slug,
-		Name:    existingRecitation.Name,
+		Reciter:     reciter,
+		Slug:        slug,
+		Name:        existingRecitation.Name,
+		AutoLafzize: existingRecitation.AutoLafzize,
 	}
 
-	var updateRequest sqlc.RecitationUpdateRecitationParams
+	var updateRequest updateRecitationDTO
 	if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
@@ -182,6 +206,10 @@ func UpdateRecitation(w http.ResponseWriter, r *http.Request) {
 		updatedRecitationData.Name = updateRequest.Name
 	}
 
+	if updateRequest.AutoLafzize != nil {
+		updatedRecitationData.AutoLafzize = sql.NullBool{Bool: *updateRequest.AutoLafzize, Valid: true}
+	}
+
 	updatedRecitation, err := db.Queries.RecitationUpdateRecitation(context.Background(), *updatedRecitationData)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
@@ -218,7 +246,18 @@ func DeleteRecitation(w http.ResponseWriter, r *http.Request) {
 		Slug:    slug,
 	}
 
-	deletedRecitation, err := db.Queries.RecitationDeleteRecitation(context.Background(), request)
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error starting transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer unit.Rollback()
+
+	deletedRecitation, err := unit.Queries.RecitationDeleteRecitation(context.Background(), request)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
@@ -228,12 +267,13 @@ func DeleteRecitation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	recitationDir := filepath.Join("data", "uploads", reciter, slug)
-	err = os.RemoveAll(recitationDir)
+	unit.Remove(filepath.Join("data", "uploads", reciter, slug))
+
+	err = unit.Commit()
 	if err != nil {
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
-			"message": "Error deleting recitation directory",
+			"message": "Error deleting recitation",
 			"error":   err.Error(),
 		})
 		return
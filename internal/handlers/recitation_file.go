@@ -2,15 +2,21 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
-	"os/exec"
 	"path/filepath"
 
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/events"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/lafzize"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/transcode"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/unitofwork"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/validators"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 )
@@ -48,17 +54,11 @@ func CreateRecitationFile(w http.ResponseWriter, r *http.Request) {
 
 	verseKey := r.FormValue("verse_key")
 
-	var request sqlc.RecitationFileCreateRecitationFileParams
-
-	request.Reciter = reciter
-	request.Slug = slug
-	request.VerseKey = verseKey
-
-	recitationFile, err := db.Queries.RecitationFileCreateRecitationFile(context.Background(), request)
+	err = validators.ValidateVerseKey(verseKey)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error creating recitation file",
+			"message": "Invalid verse key",
 			"error":   err.Error(),
 		})
 		return
@@ -75,56 +75,65 @@ func CreateRecitationFile(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	baseDir := filepath.Join("data", "uploads", request.Reciter, request.Slug)
-	err = os.MkdirAll(baseDir, 0755)
+	var request sqlc.RecitationFileCreateRecitationFileParams
+
+	request.Reciter = reciter
+	request.Slug = slug
+	request.VerseKey = verseKey
+
+	unit, err := unitofwork.Begin(context.Background())
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
-			"message": "Error creating recitation directory",
+			"message": "Error starting transaction",
 			"error":   err.Error(),
 		})
 		return
 	}
+	defer unit.Rollback()
 
-	rawFilepath := filepath.Join(baseDir, request.VerseKey+".raw")
-	transcodedFilepath := filepath.Join(baseDir, request.VerseKey+".mp3")
-
-	fileHandler, err := os.Create(rawFilepath)
+	_, err = unit.Queries.RecitationFileCreateRecitationFile(context.Background(), request)
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
 			"message": "Error creating recitation file",
 			"error":   err.Error(),
 		})
 		return
 	}
-	defer fileHandler.Close()
 
-	if _, err := io.Copy(fileHandler, file); err != nil {
-		render.Status(r, http.StatusBadRequest)
+	err = stageUpload(unit, reciter, slug, request.VerseKey, file)
+	if err == nil {
+		err = transcode.Enqueue(context.Background(), unit, reciter, slug, request.VerseKey)
+	}
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
-			"message": "Error copying recitation file contents",
+			"message": "Error saving recitation file",
 			"error":   err.Error(),
 		})
 		return
 	}
-	cmd := exec.Command("ffmpeg", "-i", rawFilepath, transcodedFilepath)
 
-	_, err = cmd.CombinedOutput()
+	err = unit.Commit()
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
-			"message": "Error transcoding recitation file",
+			"message": "Error saving recitation file",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	err = os.RemoveAll(rawFilepath)
+	recitationFile, err := db.Queries.RecitationFileSelectRecitationFile(context.Background(), sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: request.VerseKey,
+	})
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, render.M{
-			"message": "Error deleting raw recitation file",
+			"message": "Error querying recitation file",
 			"error":   err.Error(),
 		})
 		return
@@ -133,6 +142,53 @@ func CreateRecitationFile(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, recitationFile)
 }
 
+// stageUpload stages uploaded audio of a verse for it to be transcoded once
+// the unit of work is committed.
+func stageUpload(unit *unitofwork.Unit, reciter string, slug string, verseKey string, content io.Reader) error {
+	fileHandler, err := unit.Create(transcode.RawPath(reciter, slug, verseKey))
+	if err != nil {
+		return fmt.Errorf("error creating recitation file: %w", err)
+	}
+	defer fileHandler.Close()
+
+	if _, err := io.Copy(fileHandler, content); err != nil {
+		return fmt.Errorf("error copying recitation file contents: %w", err)
+	}
+
+	unit.AfterCommit(func() {
+		events.Publish(events.Event{
+			Type:     events.TypeUploaded,
+			Reciter:  reciter,
+			Slug:     slug,
+			VerseKey: verseKey,
+		})
+	})
+
+	return nil
+}
+
+// recitationFileDuration returns the stored duration of a recitation file,
+// probing the audio of files uploaded before metadata was stored. It returns 0
+// if the duration is unknown, such as before the file is transcoded, which
+// skips duration checks.
+func recitationFileDuration(recitationFile sqlc.RecitationFile) float64 {
+	if recitationFile.Duration > 0 || recitationFile.TranscodeStatus != transcode.StatusDone {
+		return recitationFile.Duration
+	}
+
+	audioFilepath, err := storage.Fetch(context.Background(), filepath.Join("data", "uploads", recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey+".mp3"))
+	if err != nil {
+		log.Printf("Error fetching audio of recitation file, skipping duration checks: %v\n", err)
+		return 0
+	}
+
+	duration, err := audio.Duration(audioFilepath)
+	if err != nil {
+		log.Printf("Error probing duration of recitation file, skipping duration checks: %v\n", err)
+	}
+	return duration
+}
+
 // GetRecitationFiles godoc
 //
 //	@Tags		RecitationFile
@@ -218,7 +274,18 @@ func DeleteRecitationFile(w http.ResponseWriter, r *http.Request) {
 		VerseKey: verseKey,
 	}
 
-	deletedRecitationFile, err := db.Queries.RecitationFileDeleteRecitationFile(context.Background(), request)
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error starting transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer unit.Rollback()
+
+	deletedRecitationFile, err := unit.Queries.RecitationFileDeleteRecitationFile(context.Background(), request)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
@@ -228,17 +295,68 @@ func DeleteRecitationFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, audioFilepath := range audio.Paths(reciter, slug, verseKey) {
+		unit.Remove(audioFilepath)
+	}
+	unit.Remove(transcode.RawPath(reciter, slug, verseKey))
+	unit.Remove(filepath.Join("data", "uploads", reciter, slug, verseKey+".json"))
+	unit.Remove(lafzize.PreviousTimingsPath(reciter, slug, verseKey))
+
+	err = unit.Commit()
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error deleting recitation file",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	render.JSON(w, r, deletedRecitationFile)
+}
 
-	audioFilepath := filepath.Join("data", "uploads", reciter, slug, verseKey+".mp3")
-	timingsFilepath := filepath.Join("data", "uploads", reciter, slug, verseKey+".json")
+// RetryRecitationFileTranscode godoc
+//
+//	@Tags		RecitationFile
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string	true	"CSRF Token"
+//
+//	@Param		slug			path		string	true	"Slug"
+//	@Param		verse_key		path		string	true	"Verse Key"
+//
+//	@Success	200				{object}	sqlc.RecitationFile
+//	@Failure	400				{object}	models.Error
+//	@Failure	401				{object}	models.Error
+//	@Router		/recitation-files/{slug}/{verse_key}/transcode [post]
+func RetryRecitationFileTranscode(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+	verseKey := chi.URLParam(r, "verse_key")
 
-	err = os.RemoveAll(audioFilepath)
+	err := transcode.Retry(context.Background(), reciter, slug, verseKey)
 	if err != nil {
-		log.Printf("Error deleting audio file: %v\n", err)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error retrying transcode",
+			"error":   err.Error(),
+		})
+		return
 	}
-	os.RemoveAll(timingsFilepath)
+
+	recitationFile, err := db.Queries.RecitationFileSelectRecitationFile(context.Background(), sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
 	if err != nil {
-		log.Printf("Error deleting timings file: %v\n", err)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying recitation file",
+			"error":   err.Error(),
+		})
+		return
 	}
+
+	render.JSON(w, r, recitationFile)
 }
@@ -3,15 +3,21 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/events"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/lafzize"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/revisions"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/unitofwork"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/validators"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 )
@@ -29,7 +35,7 @@ import (
 //	@Param		request			body		models.Timing	true	"Update Recitation Timing"
 //
 //	@Success	200				{object}	models.Timing
-//	@Failure	400				{object}	models.Error
+//	@Failure	400				{object}	models.TimingValidationError
 //	@Failure	401				{object}	models.Error
 //	@Router		/recitation-timings/{slug}/{verse_key} [post]
 func UpdateRecitationTiming(w http.ResponseWriter, r *http.Request) {
@@ -68,70 +74,166 @@ func UpdateRecitationTiming(w http.ResponseWriter, r *http.Request) {
 			"message": "Error parsing request JSON",
 			"error":   err.Error(),
 		})
-		log.Println(err)
 		return
 	}
 	defer r.Body.Close()
 
-	baseDir := filepath.Join("data", "uploads", reciter, slug)
-	err = os.MkdirAll(baseDir, 0755)
+	validationErrors := validators.ValidateTiming(verseKey, timing, recitationFileDuration(existingRecitationFile))
+	if len(validationErrors) > 0 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, models.TimingValidationError{
+			Message: "Invalid recitation timing",
+			Error:   fmt.Sprintf("%d validation errors", len(validationErrors)),
+			Errors:  validationErrors,
+		})
+		return
+	}
+
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error starting transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer unit.Rollback()
+
+	err = saveRecitationTiming(unit, reciter, slug, verseKey, timing)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error creating recitation directory",
+			"message": "Error saving recitation timing",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	timingsFilepath := filepath.Join(baseDir, verseKey+".json")
-	err = os.RemoveAll(timingsFilepath)
+	_, err = revisions.Record(context.Background(), unit.Queries, reciter, slug, verseKey, reciter, revisions.SourceManual, timing)
 	if err != nil {
-		log.Printf("Error removing existing timings file: %v\n", err)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error recording timing revision",
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	fileHandler, err := os.Create(timingsFilepath)
+	err = unit.Commit()
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error saving recitation timing",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, timing)
+}
+
+// DeleteRecitationTiming godoc
+//
+//	@Tags		RecitationTiming
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string	true	"CSRF Token"
+//
+//	@Param		slug			path		string	true	"Slug"
+//	@Param		verse_key		path		string	true	"Verse Key"
+//
+//	@Success	200				{object}	models.Timing
+//	@Failure	400				{object}	models.Error
+//	@Failure	401				{object}	models.Error
+//	@Router		/recitation-timings/{slug}/{verse_key} [delete]
+func DeleteRecitationTiming(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+	verseKey := chi.URLParam(r, "verse_key")
+
+	existingRecitationFile, err := db.Queries.RecitationFileSelectRecitationFile(context.Background(), sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error creating recitation file",
+			"message": "Error checking status of recitation file",
 			"error":   err.Error(),
 		})
 		return
 	}
-	defer fileHandler.Close()
+	if existingRecitationFile.LafzizeProcessing {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "The recitation is currently being lafzized",
+			"error":   "",
+		})
+		return
+	}
 
-	jsonData, err := json.Marshal(timing)
+	baseDir := filepath.Join("data", "uploads", reciter, slug)
+	timingsFilepath := filepath.Join(baseDir, fmt.Sprintf("%s.json", verseKey))
+
+	file, err := storage.Open(context.Background(), timingsFilepath)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error saving JSON file",
+			"message": "Error opening recitation timing",
 			"error":   err.Error(),
 		})
 		return
 	}
+	defer file.Close()
 
-	err = os.WriteFile(timingsFilepath, jsonData, 0644)
+	decoder := json.NewDecoder(file)
+	var timing models.Timing
+	err = decoder.Decode(&timing)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error saving JSON file",
+			"message": "Error reading recitation timing",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	_, err = db.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error starting transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer unit.Rollback()
+
+	unit.Remove(timingsFilepath)
+
+	_, err = unit.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
 		Reciter:           reciter,
 		Slug:              slug,
 		VerseKey:          verseKey,
-		HasTimings:        true,
+		HasTimings:        false,
 		LafzizeProcessing: false,
 	})
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error updating status of recitation file",
+			"message": "Error updating status of recitation file.",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = unit.Commit()
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error deleting recitation timing",
 			"error":   err.Error(),
 		})
 		return
@@ -140,7 +242,7 @@ func UpdateRecitationTiming(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, timing)
 }
 
-// DeleteRecitationTiming godoc
+// RestoreRecitationTiming godoc
 //
 //	@Tags		RecitationTiming
 //	@Produce	json
@@ -153,8 +255,8 @@ func UpdateRecitationTiming(w http.ResponseWriter, r *http.Request) {
 //	@Success	200				{object}	models.Timing
 //	@Failure	400				{object}	models.Error
 //	@Failure	401				{object}	models.Error
-//	@Router		/recitation-timings/{slug}/{verse_key} [delete]
-func DeleteRecitationTiming(w http.ResponseWriter, r *http.Request) {
+//	@Router		/recitation-timings/{slug}/{verse_key}/restore [post]
+func RestoreRecitationTiming(w http.ResponseWriter, r *http.Request) {
 	reciter := r.Context().Value("username").(string)
 	slug := chi.URLParam(r, "slug")
 	verseKey := chi.URLParam(r, "verse_key")
@@ -181,53 +283,100 @@ func DeleteRecitationTiming(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	baseDir := filepath.Join("data", "uploads", reciter, slug)
-	timingsFilepath := filepath.Join(baseDir, fmt.Sprintf("%s.json", verseKey))
+	timingsFilepath := filepath.Join("data", "uploads", reciter, slug, fmt.Sprintf("%s.json", verseKey))
+	previousFilepath := lafzize.PreviousTimingsPath(reciter, slug, verseKey)
 
-	file, err := os.Open(timingsFilepath)
+	previousData, err := storage.ReadFile(context.Background(), previousFilepath)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error opening recitation timing",
+			"message": "Error opening previous recitation timing",
 			"error":   err.Error(),
 		})
 		return
 	}
-	defer file.Close()
 
-	decoder := json.NewDecoder(file)
 	var timing models.Timing
-	err = decoder.Decode(&timing)
+	err = json.Unmarshal(previousData, &timing)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error reading recitation timing",
+			"message": "Error reading previous recitation timing",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	err = os.RemoveAll(timingsFilepath)
-	if err != nil {
+	currentData, err := storage.ReadFile(context.Background(), timingsFilepath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error deleting recitation timing",
+			"message": "Error opening current recitation timing",
+			"error":   err.Error(),
+		})
+		return
+	}
+	hadTimings := err == nil
+
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error starting transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer unit.Rollback()
+
+	// Swap the current and previous timings, so that restoring can be undone
+	// by restoring again.
+	err = unit.WriteFile(timingsFilepath, previousData)
+	if err == nil && hadTimings {
+		err = unit.WriteFile(previousFilepath, currentData)
+	} else if err == nil {
+		unit.Remove(previousFilepath)
+	}
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error staging recitation timing",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	_, err = db.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
+	_, err = unit.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
 		Reciter:           reciter,
 		Slug:              slug,
 		VerseKey:          verseKey,
-		HasTimings:        false,
+		HasTimings:        true,
 		LafzizeProcessing: false,
 	})
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
-			"message": "Error updating status of recitation file.",
+			"message": "Error updating status of recitation file",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	_, err = revisions.Record(context.Background(), unit.Queries, reciter, slug, verseKey, reciter, revisions.SourceRestore, timing)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error recording timing revision",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = unit.Commit()
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error restoring previous recitation timing",
 			"error":   err.Error(),
 		})
 		return
@@ -235,3 +384,39 @@ func DeleteRecitationTiming(w http.ResponseWriter, r *http.Request) {
 
 	render.JSON(w, r, timing)
 }
+
+// saveRecitationTiming stages the timings file of a recitation file and marks
+// it as having timings. Subscribers are notified once the unit is committed.
+func saveRecitationTiming(unit *unitofwork.Unit, reciter string, slug string, verseKey string, timing models.Timing) error {
+	jsonData, err := json.Marshal(timing)
+	if err != nil {
+		return fmt.Errorf("error saving JSON file: %w", err)
+	}
+
+	err = unit.WriteFile(filepath.Join("data", "uploads", reciter, slug, verseKey+".json"), jsonData)
+	if err != nil {
+		return fmt.Errorf("error saving JSON file: %w", err)
+	}
+
+	_, err = unit.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
+		Reciter:           reciter,
+		Slug:              slug,
+		VerseKey:          verseKey,
+		HasTimings:        true,
+		LafzizeProcessing: false,
+	})
+	if err != nil {
+		return fmt.Errorf("error updating status of recitation file: %w", err)
+	}
+
+	unit.AfterCommit(func() {
+		events.Publish(events.Event{
+			Type:     events.TypeTimingsUpdated,
+			Reciter:  reciter,
+			Slug:     slug,
+			VerseKey: verseKey,
+		})
+	})
+
+	return nil
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/aligner"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/revisions"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/transcode"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/unitofwork"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/validators"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/spf13/viper"
+)
+
+type splitVerseDTO struct {
+	VerseKey   string  `json:"verse_key"`
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	HasTimings bool    `json:"has_timings"`
+	timing     *models.Timing
+}
+
+type splitSummaryDTO struct {
+	Chapter  int             `json:"chapter"`
+	Imported int             `json:"imported"`
+	Skipped  int             `json:"skipped"`
+	Failed   int             `json:"failed"`
+	Verses   []splitVerseDTO `json:"verses"`
+}
+
+// SplitRecitationFile godoc
+//
+//	@Tags		RecitationFile
+//	@Accept		multipart/form-data
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string	true	"CSRF Token"
+//
+//	@Param		slug			path		string	true	"Slug"
+//	@Param		file			formData	file	true	"Audio of the chapter"
+//	@Param		chapter			formData	int		true	"Chapter"
+//	@Param		from			formData	int		false	"Verse the audio starts at"	default(1)
+//	@Param		boundaries		formData	string	false	"JSON array of ordered, non-overlapping [start, end] seconds of each verse, the last end may be -1 for the end of the audio"
+//	@Param		alignment		formData	string	false	"JSON word level alignment of the chapter, in the format of recitation timings. The chapter is aligned with the configured aligner if neither boundaries nor alignment is given"
+//
+//	@Success	200				{object}	splitSummaryDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	401				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/recitation-files/{slug}/chapter [post]
+func SplitRecitationFile(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+
+	err := r.ParseMultipartForm(32 << 20) // Larger recordings are buffered on disk
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error parsing multipart form",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	chapter, err := strconv.Atoi(r.FormValue("chapter"))
+	if err == nil && quran.VerseCount(chapter) == 0 {
+		err = fmt.Errorf("there is no chapter %d", chapter)
+	}
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid chapter",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	from := 1
+	if value := r.FormValue("from"); value != "" {
+		from, err = strconv.Atoi(value)
+		if err == nil && (from < 1 || from > quran.VerseCount(chapter)) {
+			err = fmt.Errorf("chapter %d has %d verses", chapter, quran.VerseCount(chapter))
+		}
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, render.M{
+				"message": "Invalid starting verse",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	var verses []splitVerseDTO
+	switch {
+	case r.FormValue("boundaries") != "" && r.FormValue("alignment") != "":
+		err = errors.New("only one of boundaries and alignment may be given")
+	case r.FormValue("boundaries") != "":
+		verses, err = splitByBoundaries(chapter, from, r.FormValue("boundaries"))
+	case r.FormValue("alignment") != "":
+		var alignment models.Timing
+		err = json.Unmarshal([]byte(r.FormValue("alignment")), &alignment)
+		if err == nil {
+			verses, err = splitByAlignment(chapter, from, alignment)
+		}
+	}
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Invalid verse boundaries",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error retrieving uploaded file",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	baseDir := filepath.Join("data", "uploads", reciter, slug)
+	err = os.MkdirAll(baseDir, 0755)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error creating recitation directory",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rawFile, err := os.CreateTemp(baseDir, fmt.Sprintf("chapter-%d-*.raw", chapter))
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error creating recitation file",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer os.Remove(rawFile.Name())
+	defer rawFile.Close()
+
+	if _, err := io.Copy(rawFile, file); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error copying recitation file contents",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if verses == nil {
+		var alignment models.Timing
+		alignment, err = alignChapter(rawFile.Name(), chapter, from)
+		if err == nil {
+			verses, err = splitByAlignment(chapter, from, alignment)
+		}
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, render.M{
+				"message": "Error aligning chapter",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	summary := splitSummaryDTO{Chapter: chapter, Verses: verses}
+	for i := range summary.Verses {
+		verse := &summary.Verses[i]
+		verse.Status, verse.Error = splitVerse(reciter, slug, rawFile.Name(), verse)
+
+		switch verse.Status {
+		case importImported:
+			summary.Imported++
+		case importSkipped:
+			summary.Skipped++
+		case importFailed:
+			summary.Failed++
+		}
+	}
+
+	render.JSON(w, r, summary)
+}
+
+// splitByBoundaries parses a JSON array of [start, end] pairs, one per verse
+// starting at the given verse. The pairs must be in order and must not overlap,
+// and only the last may end at -1, the end of the audio.
+func splitByBoundaries(chapter int, from int, data string) ([]splitVerseDTO, error) {
+	var boundaries [][2]float64
+	err := json.Unmarshal([]byte(data), &boundaries)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(boundaries) == 0 || from+len(boundaries)-1 > quran.VerseCount(chapter) {
+		return nil, fmt.Errorf("expected between 1 and %d boundaries, got %d", quran.VerseCount(chapter)-from+1, len(boundaries))
+	}
+
+	verses := []splitVerseDTO{}
+	for i, boundary := range boundaries {
+		last := i == len(boundaries)-1
+		if boundary[0] < 0 || ((boundary[1] != -1 || !last) && boundary[1] <= boundary[0]) {
+			return nil, fmt.Errorf("invalid boundary %v of verse %d", boundary, from+i)
+		}
+		if i > 0 && boundary[0] < boundaries[i-1][1] {
+			return nil, fmt.Errorf("boundary %v of verse %d starts before the previous verse ends at %v", boundary, from+i, boundaries[i-1][1])
+		}
+
+		verses = append(verses, splitVerseDTO{
+			VerseKey: quran.VerseKey(chapter, from+i),
+			Start:    boundary[0],
+			End:      boundary[1],
+		})
+	}
+
+	return verses, nil
+}
+
+// alignChapter aligns a recording of a chapter from the given verse to its
+// end with the configured aligner.
+func alignChapter(audioPath string, chapter int, from int) (models.Timing, error) {
+	backend, err := aligner.New()
+	if err != nil {
+		return models.Timing{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("lafzize_timeout"))
+	defer cancel()
+
+	return backend.Align(ctx, audioPath, quran.VerseRange(chapter, from, quran.VerseCount(chapter)))
+}
+
+// splitByAlignment groups the word segments of a chapter alignment into
+// verses using the word counts of each verse. Cuts are placed halfway through
+// the silence between verses, and the timings of each verse are rebased to
+// start at its cut.
+func splitByAlignment(chapter int, from int, alignment models.Timing) ([]splitVerseDTO, error) {
+	verses := []splitVerseDTO{}
+	segments := alignment.Segments
+	for verse := from; len(segments) > 0; verse++ {
+		if verse > quran.VerseCount(chapter) {
+			return nil, fmt.Errorf("%d segments remain after the last verse of chapter %d", len(segments), chapter)
+		}
+
+		verseKey := quran.VerseKey(chapter, verse)
+		words, _ := quran.Words(verseKey)
+		if len(words) > len(segments) {
+			return nil, fmt.Errorf("%s has %d words but only %d segments remain", verseKey, len(words), len(segments))
+		}
+
+		verses = append(verses, splitVerseDTO{
+			VerseKey: verseKey,
+			Start:    segments[0].Start,
+			End:      segments[len(words)-1].End,
+			timing:   &models.Timing{Segments: segments[:len(words)]},
+		})
+		segments = segments[len(words):]
+	}
+
+	if len(verses) == 0 {
+		return nil, errors.New("the alignment has no segments")
+	}
+
+	for i := range verses {
+		if i > 0 {
+			verses[i].Start = (verses[i-1].timing.Segments[len(verses[i-1].timing.Segments)-1].End + verses[i].Start) / 2
+		} else {
+			verses[i].Start = 0
+		}
+		if i < len(verses)-1 {
+			verses[i].End = (verses[i].End + verses[i+1].Start) / 2
+		} else {
+			verses[i].End = -1
+		}
+	}
+
+	for i := range verses {
+		rebased := models.Timing{Segments: []models.Segment{}}
+		for _, segment := range verses[i].timing.Segments {
+			segment.Start -= verses[i].Start
+			segment.End -= verses[i].Start
+			rebased.Segments = append(rebased.Segments, segment)
+		}
+		verses[i].timing = &rebased
+	}
+
+	return verses, nil
+}
+
+// splitVerse cuts a verse out of the audio of a chapter, then creates its
+// recitation file, queues it for transcoding and saves its timings, if known
+// and valid, in one unit of work.
+func splitVerse(reciter string, slug string, rawFilepath string, verse *splitVerseDTO) (string, string) {
+	cutFile, err := os.CreateTemp(filepath.Dir(rawFilepath), "verse-*.raw")
+	if err != nil {
+		return importFailed, fmt.Sprintf("error creating verse file: %v", err)
+	}
+	cutFile.Close()
+	defer os.Remove(cutFile.Name())
+
+	options := []string{"-ss", strconv.FormatFloat(verse.Start, 'f', 3, 64)}
+	if verse.End >= 0 {
+		options = append(options, "-to", strconv.FormatFloat(verse.End, 'f', 3, 64))
+	}
+
+	// The verse is cut before the unit of work begins, so that the database is
+	// not locked while waiting for ffmpeg.
+	err = transcode.Cut(rawFilepath, options, cutFile.Name())
+	if err != nil {
+		return importFailed, fmt.Sprintf("error cutting verse: %v", err)
+	}
+
+	cut, err := os.Open(cutFile.Name())
+	if err != nil {
+		return importFailed, fmt.Sprintf("error opening verse file: %v", err)
+	}
+	defer cut.Close()
+
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		return importFailed, err.Error()
+	}
+	defer unit.Rollback()
+
+	_, err = unit.Queries.RecitationFileCreateRecitationFile(context.Background(), sqlc.RecitationFileCreateRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verse.VerseKey,
+	})
+	if err != nil {
+		return importSkipped, fmt.Sprintf("error creating recitation file, it may already exist: %v", err)
+	}
+
+	err = stageUpload(unit, reciter, slug, verse.VerseKey, cut)
+	if err == nil {
+		err = transcode.Enqueue(context.Background(), unit, reciter, slug, verse.VerseKey)
+	}
+	if err != nil {
+		return importFailed, err.Error()
+	}
+
+	timingsError := ""
+	hasTimings := false
+	if verse.timing != nil {
+		duration := 0.0
+		if verse.End >= 0 {
+			duration = verse.End - verse.Start
+		}
+
+		validationErrors := validators.ValidateTiming(verse.VerseKey, *verse.timing, duration)
+		if len(validationErrors) > 0 {
+			timingsError = fmt.Sprintf("timings not saved, %d validation errors", len(validationErrors))
+		} else {
+			err = saveRecitationTiming(unit, reciter, slug, verse.VerseKey, *verse.timing)
+			if err == nil {
+				_, err = revisions.Record(context.Background(), unit.Queries, reciter, slug, verse.VerseKey, reciter, revisions.SourceSplit, *verse.timing)
+			}
+			if err != nil {
+				return importFailed, fmt.Sprintf("error saving timings: %v", err)
+			}
+			hasTimings = true
+		}
+	}
+
+	err = unit.Commit()
+	if err != nil {
+		return importFailed, err.Error()
+	}
+
+	verse.HasTimings = hasTimings
+	return importImported, timingsError
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/revisions"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/unitofwork"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type timingRevisionDTO struct {
+	ID        int64         `json:"id"`
+	Reciter   string        `json:"reciter"`
+	Slug      string        `json:"slug"`
+	VerseKey  string        `json:"verse_key"`
+	Author    string        `json:"author"`
+	Source    string        `json:"source"`
+	CreatedAt time.Time     `json:"created_at"`
+	Timing    models.Timing `json:"timing"`
+}
+
+type timingRevisionDiffDTO struct {
+	From     int64                `json:"from"`
+	To       int64                `json:"to"`
+	Segments []models.SegmentDiff `json:"segments"`
+}
+
+// GetRecitationTimingRevisions godoc
+//
+//	@Tags		RecitationTiming
+//	@Produce	json
+//
+//	@Param		reciter		path		string	true	"Reciter"
+//	@Param		slug		path		string	true	"Slug"
+//	@Param		verse_key	path		string	true	"Verse Key"
+//
+//	@Success	200			{object}	[]sqlc.TimingRevisionSelectTimingRevisionsRow
+//	@Failure	500			{object}	models.Error
+//	@Router		/recitation-timings/{reciter}/{slug}/{verse_key}/revisions [get]
+func GetRecitationTimingRevisions(w http.ResponseWriter, r *http.Request) {
+	timingRevisions, err := db.Queries.TimingRevisionSelectTimingRevisions(context.Background(), sqlc.TimingRevisionSelectTimingRevisionsParams{
+		Reciter:  chi.URLParam(r, "reciter"),
+		Slug:     chi.URLParam(r, "slug"),
+		VerseKey: chi.URLParam(r, "verse_key"),
+	})
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error querying timing revisions",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, timingRevisions)
+}
+
+// GetRecitationTimingRevision godoc
+//
+//	@Tags		RecitationTiming
+//	@Produce	json
+//
+//	@Param		reciter		path		string	true	"Reciter"
+//	@Param		slug		path		string	true	"Slug"
+//	@Param		verse_key	path		string	true	"Verse Key"
+//	@Param		id			path		int		true	"Revision ID"
+//
+//	@Success	200			{object}	timingRevisionDTO
+//	@Failure	400			{object}	models.Error
+//	@Router		/recitation-timings/{reciter}/{slug}/{verse_key}/revisions/{id} [get]
+func GetRecitationTimingRevision(w http.ResponseWriter, r *http.Request) {
+	timingRevision, err := selectTimingRevision(chi.URLParam(r, "reciter"), chi.URLParam(r, "slug"), chi.URLParam(r, "verse_key"), chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error querying timing revision",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, timingRevision)
+}
+
+// DiffRecitationTimingRevisions godoc
+//
+//	@Tags		RecitationTiming
+//	@Produce	json
+//
+//	@Param		reciter		path		string	true	"Reciter"
+//	@Param		slug		path		string	true	"Slug"
+//	@Param		verse_key	path		string	true	"Verse Key"
+//	@Param		id			path		int		true	"Revision ID to diff from"
+//	@Param		other_id	path		int		true	"Revision ID to diff to"
+//
+//	@Success	200			{object}	timingRevisionDiffDTO
+//	@Failure	400			{object}	models.Error
+//	@Router		/recitation-timings/{reciter}/{slug}/{verse_key}/revisions/{id}/diff/{other_id} [get]
+func DiffRecitationTimingRevisions(w http.ResponseWriter, r *http.Request) {
+	reciter := chi.URLParam(r, "reciter")
+	slug := chi.URLParam(r, "slug")
+	verseKey := chi.URLParam(r, "verse_key")
+
+	from, err := selectTimingRevision(reciter, slug, verseKey, chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error querying timing revision",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	to, err := selectTimingRevision(reciter, slug, verseKey, chi.URLParam(r, "other_id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error querying timing revision",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, timingRevisionDiffDTO{
+		From:     from.ID,
+		To:       to.ID,
+		Segments: revisions.Diff(from.Timing, to.Timing),
+	})
+}
+
+// RestoreRecitationTimingRevision godoc
+//
+//	@Tags		RecitationTiming
+//	@Produce	json
+//
+//	@Param		X-CSRF-TOKEN	header		string	true	"CSRF Token"
+//
+//	@Param		slug			path		string	true	"Slug"
+//	@Param		verse_key		path		string	true	"Verse Key"
+//	@Param		id				path		int		true	"Revision ID"
+//
+//	@Success	200				{object}	timingRevisionDTO
+//	@Failure	400				{object}	models.Error
+//	@Failure	401				{object}	models.Error
+//	@Failure	500				{object}	models.Error
+//	@Router		/recitation-timings/{slug}/{verse_key}/revisions/{id}/restore [post]
+func RestoreRecitationTimingRevision(w http.ResponseWriter, r *http.Request) {
+	reciter := r.Context().Value("username").(string)
+	slug := chi.URLParam(r, "slug")
+	verseKey := chi.URLParam(r, "verse_key")
+
+	existingRecitationFile, err := db.Queries.RecitationFileSelectRecitationFile(context.Background(), sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error checking status of recitation file",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if existingRecitationFile.LafzizeProcessing {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "The recitation is currently being lafzized",
+			"error":   "",
+		})
+		return
+	}
+
+	timingRevision, err := selectTimingRevision(reciter, slug, verseKey, chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, render.M{
+			"message": "Error querying timing revision",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	unit, err := unitofwork.Begin(context.Background())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error starting transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer unit.Rollback()
+
+	err = saveRecitationTiming(unit, reciter, slug, verseKey, timingRevision.Timing)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error saving recitation timing",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	restoredRevision, err := revisions.Record(context.Background(), unit.Queries, reciter, slug, verseKey, reciter, revisions.SourceRestore, timingRevision.Timing)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error recording timing revision",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = unit.Commit()
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error saving recitation timing",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	dto, err := newTimingRevisionDTO(restoredRevision)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error reading timing revision",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	render.JSON(w, r, dto)
+}
+
+func selectTimingRevision(reciter string, slug string, verseKey string, id string) (timingRevisionDTO, error) {
+	revisionID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return timingRevisionDTO{}, err
+	}
+
+	timingRevision, err := db.Queries.TimingRevisionSelectTimingRevision(context.Background(), sqlc.TimingRevisionSelectTimingRevisionParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+		ID:       revisionID,
+	})
+	if err != nil {
+		return timingRevisionDTO{}, err
+	}
+
+	return newTimingRevisionDTO(timingRevision)
+}
+
+func newTimingRevisionDTO(timingRevision sqlc.TimingRevision) (timingRevisionDTO, error) {
+	dto := timingRevisionDTO{
+		ID:        timingRevision.ID,
+		Reciter:   timingRevision.Reciter,
+		Slug:      timingRevision.Slug,
+		VerseKey:  timingRevision.VerseKey,
+		Author:    timingRevision.Author,
+		Source:    timingRevision.Source,
+		CreatedAt: timingRevision.CreatedAt,
+	}
+
+	err := json.Unmarshal([]byte(timingRevision.Timing), &dto.Timing)
+	return dto, err
+}
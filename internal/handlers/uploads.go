@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/spf13/viper"
+)
+
+// GetUpload godoc
+//
+//	@Tags		RecitationFile
+//	@Produce	audio/mpeg
+//	@Produce	json
+//
+//	@Param		path	path	string	true	"Path of the file, such as {reciter}/{slug}/{verse_key}.mp3"
+//
+//	@Success	200
+//	@Success	302
+//	@Failure	404	{object}	models.Error
+//	@Router		/uploads/{path} [get]
+func GetUpload(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(chi.URLParam(r, "*"), "/")
+	uploadFilepath := filepath.Join("data", "uploads", filepath.FromSlash(key))
+
+	if _, ok := storage.Key(uploadFilepath); !ok {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "File not found",
+			"error":   "",
+		})
+		return
+	}
+
+	serveUpload(w, r, uploadFilepath)
+}
+
+// serveUpload serves a file of the uploads. With a remote storage backend,
+// clients are redirected to a signed URL of the file instead, which fails by
+// itself if the file does not exist.
+func serveUpload(w http.ResponseWriter, r *http.Request, uploadFilepath string) {
+	if storage.IsLocal() {
+		http.ServeFile(w, r, uploadFilepath)
+		return
+	}
+
+	key, ok := storage.ResolvedKey(uploadFilepath)
+	if !ok {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, render.M{
+			"message": "File not found",
+			"error":   "",
+		})
+		return
+	}
+
+	signedURL, err := storage.Backend.SignedURL(context.Background(), key, viper.GetDuration("s3_signed_url_expiry"))
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, render.M{
+			"message": "Error signing file URL",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	http.Redirect(w, r, signedURL, http.StatusFound)
+}
@@ -4,11 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"os"
 	"path/filepath"
 
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 )
@@ -158,7 +158,7 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userDir := filepath.Join("data", "uploads", username)
-	err = os.RemoveAll(userDir)
+	err = storage.Remove(context.Background(), userDir)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, render.M{
@@ -0,0 +1,435 @@
+package lafzize
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/aligner"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/events"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/revisions"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"github.com/spf13/viper"
+)
+
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// How often idle workers check the jobs table when they have not been woken up.
+const pollInterval = 5 * time.Second
+
+// The longest that a job waits before being retried, however many attempts it
+// has made.
+const maxBackoff = 10 * time.Minute
+
+var ErrProcessing = errors.New("the recitation is already being lafzized")
+var ErrNotProcessing = errors.New("the recitation is not being lafzized")
+
+var wake = make(chan struct{}, 1)
+
+type runningJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// mutex serialises claiming jobs with cancelling them, so that a job is
+// always either queued or registered in running.
+var mutex sync.Mutex
+var running = map[int64]*runningJob{}
+
+var backend aligner.Aligner
+
+// Start recovers jobs that were in flight when the server last stopped and
+// starts the worker pool.
+func Start() {
+	var err error
+	backend, err = aligner.New()
+	if err != nil {
+		log.Fatalf("Error creating aligner: %v", err)
+	}
+
+	err = db.Queries.LafzizeJobRequeueRunningLafzizeJobs(context.Background())
+	if err != nil {
+		log.Fatalf("Error requeueing interrupted lafzize jobs: %v", err)
+	}
+
+	err = db.Queries.RecitationFileResetStaleLafzizeProcessing(context.Background())
+	if err != nil {
+		log.Fatalf("Error resetting stale lafzize processing flags: %v", err)
+	}
+
+	for range viper.GetInt("lafzize_concurrency") {
+		go worker()
+	}
+}
+
+// Schedule marks a recitation file as being lafzized and queues a job for it,
+// optionally as part of a batch. Both happen in one transaction, so that a
+// recitation file is never marked without a job, nor queued twice.
+func Schedule(ctx context.Context, reciter string, slug string, verseKey string, batchID sql.NullInt64) (sqlc.LafzizeJob, error) {
+	_, err := db.Queries.RecitationFileSelectRecitationFile(ctx, sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		return sqlc.LafzizeJob{}, err
+	}
+
+	_, err = storage.Stat(ctx, audioPath(reciter, slug, verseKey))
+	if err != nil {
+		return sqlc.LafzizeJob{}, err
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlc.LafzizeJob{}, err
+	}
+	defer tx.Rollback()
+	queries := db.Queries.WithTx(tx)
+
+	marked, err := queries.RecitationFileMarkLafzizeProcessing(ctx, sqlc.RecitationFileMarkLafzizeProcessingParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		return sqlc.LafzizeJob{}, err
+	}
+	if marked == 0 {
+		return sqlc.LafzizeJob{}, ErrProcessing
+	}
+
+	job, err := queries.LafzizeJobCreateLafzizeJob(ctx, sqlc.LafzizeJobCreateLafzizeJobParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+		BatchID:  batchID,
+	})
+	if err != nil {
+		return sqlc.LafzizeJob{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return sqlc.LafzizeJob{}, err
+	}
+
+	events.Publish(events.Event{
+		Type:     events.TypeLafzizeQueued,
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Cancel aborts the latest job of a recitation file, whether it is still
+// queued or already running, and waits for it to be wound up.
+func Cancel(ctx context.Context, reciter string, slug string, verseKey string) (sqlc.LafzizeJob, error) {
+	job, err := db.Queries.LafzizeJobSelectLatestLafzizeJob(ctx, sqlc.LafzizeJobSelectLatestLafzizeJobParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		return job, err
+	}
+
+	mutex.Lock()
+	cancelled, err := db.Queries.LafzizeJobCancelQueuedLafzizeJob(ctx, job.ID)
+	current, isRunning := running[job.ID]
+	mutex.Unlock()
+
+	switch {
+	case err == nil:
+		finish(cancelled, StateCancelled, "")
+	case !errors.Is(err, sql.ErrNoRows):
+		return job, err
+	case isRunning:
+		current.cancel()
+		<-current.done
+	default:
+		return job, ErrNotProcessing
+	}
+
+	return db.Queries.LafzizeJobSelectLafzizeJob(ctx, job.ID)
+}
+
+// Auto reports whether uploads to a recitation should be lafzized
+// automatically, preferring the recitation's own setting over the server's.
+func Auto(reciter string, slug string) bool {
+	recitation, err := db.Queries.RecitationSelectRecitation(context.Background(), sqlc.RecitationSelectRecitationParams{
+		Reciter: reciter,
+		Slug:    slug,
+	})
+	if err == nil && recitation.AutoLafzize.Valid {
+		return recitation.AutoLafzize.Bool
+	}
+
+	return viper.GetBool("auto_lafzize")
+}
+
+func worker() {
+	for {
+		mutex.Lock()
+		job, err := db.Queries.LafzizeJobClaimLafzizeJob(context.Background())
+		var ctx context.Context
+		if err == nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), viper.GetDuration("lafzize_timeout"))
+			running[job.ID] = &runningJob{cancel: cancel, done: make(chan struct{})}
+		}
+		mutex.Unlock()
+
+		if errors.Is(err, sql.ErrNoRows) {
+			select {
+			case <-wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("Error claiming lafzize job: %v\n", err)
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		process(ctx, job)
+
+		mutex.Lock()
+		running[job.ID].cancel()
+		close(running[job.ID].done)
+		delete(running, job.ID)
+		mutex.Unlock()
+	}
+}
+
+// process runs a claimed job, retrying with exponential backoff, and records
+// the outcome on both the job and the recitation file.
+func process(ctx context.Context, job sqlc.LafzizeJob) {
+	retries := viper.GetInt("lafzize_retries")
+	backoff := viper.GetDuration("lafzize_backoff")
+
+	var err error
+	for {
+		job.Attempts++
+
+		err = run(ctx, job)
+		if err == nil || ctx.Err() != nil || !aligner.IsTemporary(err) || job.Attempts > int64(retries) {
+			break
+		}
+
+		delay := retryDelay(backoff, job.Attempts)
+		log.Printf("Error lafzizing recitation %s/%s/%s, retrying in %v: %v\n", job.Reciter, job.Slug, job.VerseKey, delay, err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		finish(job, StateCancelled, "")
+	case err != nil:
+		log.Printf("Error lafzizing recitation %s/%s/%s: %v\n", job.Reciter, job.Slug, job.VerseKey, err)
+		finish(job, StateFailed, err.Error())
+	default:
+		finish(job, StateSucceeded, "")
+	}
+}
+
+// retryDelay doubles the backoff for every attempt after the first, up to
+// maxBackoff.
+func retryDelay(backoff time.Duration, attempts int64) time.Duration {
+	delay := min(max(backoff, 0), maxBackoff)
+	for i := int64(1); i < attempts && 0 < delay && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// finish records the final state of a job. The timings produced by a
+// successful job replace the current ones, which are kept as the previous
+// timings of the recitation file.
+func finish(job sqlc.LafzizeJob, state string, failure string) {
+	if state == StateSucceeded {
+		err := swapTimings(job)
+		if err != nil {
+			log.Printf("Error saving timings of lafzize job %d: %v\n", job.ID, err)
+			state = StateFailed
+			failure = err.Error()
+		}
+	}
+
+	if state == StateSucceeded {
+		err := recordRevision(job)
+		if err != nil {
+			log.Printf("Error recording timing revision of lafzize job %d: %v\n", job.ID, err)
+		}
+
+	}
+
+	err := os.RemoveAll(temporaryTimingsPath(job.Reciter, job.Slug, job.VerseKey))
+	if err != nil {
+		log.Printf("Error removing temporary timings of lafzize job %d: %v\n", job.ID, err)
+	}
+
+	hasTimings := storage.Exists(context.Background(), timingsPath(job.Reciter, job.Slug, job.VerseKey))
+
+	_, err = db.Queries.RecitationFileUpdateRecitationFile(context.Background(), sqlc.RecitationFileUpdateRecitationFileParams{
+		Reciter:           job.Reciter,
+		Slug:              job.Slug,
+		VerseKey:          job.VerseKey,
+		HasTimings:        hasTimings,
+		LafzizeProcessing: false,
+	})
+	if err != nil {
+		log.Printf("Error updating status of recitation file for lafzize job %d: %v\n", job.ID, err)
+	}
+
+	err = db.Queries.RecitationFileUpdateLafzizeError(context.Background(), sqlc.RecitationFileUpdateLafzizeErrorParams{
+		Reciter:      job.Reciter,
+		Slug:         job.Slug,
+		VerseKey:     job.VerseKey,
+		LafzizeError: failure,
+	})
+	if err != nil {
+		log.Printf("Error updating failure reason of recitation file for lafzize job %d: %v\n", job.ID, err)
+	}
+
+	_, err = db.Queries.LafzizeJobUpdateLafzizeJob(context.Background(), sqlc.LafzizeJobUpdateLafzizeJobParams{
+		ID:       job.ID,
+		State:    state,
+		Attempts: job.Attempts,
+		Error:    failure,
+	})
+	if err != nil {
+		log.Printf("Error updating state of lafzize job %d: %v\n", job.ID, err)
+	}
+
+	eventType := events.TypeLafzizeSucceeded
+	switch state {
+	case StateFailed:
+		eventType = events.TypeLafzizeFailed
+	case StateCancelled:
+		eventType = events.TypeLafzizeCancelled
+	}
+	events.Publish(events.Event{
+		Type:     eventType,
+		Reciter:  job.Reciter,
+		Slug:     job.Slug,
+		VerseKey: job.VerseKey,
+		Error:    failure,
+	})
+}
+
+// run aligns the audio of a job once and saves the resulting timings.
+func run(ctx context.Context, job sqlc.LafzizeJob) error {
+	audioFilepath, err := storage.Fetch(ctx, audioPath(job.Reciter, job.Slug, job.VerseKey))
+	if err != nil {
+		return err
+	}
+
+	timing, err := backend.Align(ctx, audioFilepath, job.VerseKey)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(timing)
+	if err != nil {
+		return err
+	}
+
+	// With a remote backend, the directory of the recitation only exists once
+	// one of its files is fetched, which the audio is not if it is a blob.
+	temporaryPath := temporaryTimingsPath(job.Reciter, job.Slug, job.VerseKey)
+	err = os.MkdirAll(filepath.Dir(temporaryPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(temporaryPath, jsonData, 0644)
+}
+
+// swapTimings stores the timings produced by a job, keeping the timings they
+// replace as the previous timings.
+func swapTimings(job sqlc.LafzizeJob) error {
+	ctx := context.Background()
+	currentPath := timingsPath(job.Reciter, job.Slug, job.VerseKey)
+	previousPath := PreviousTimingsPath(job.Reciter, job.Slug, job.VerseKey)
+
+	jsonData, err := os.ReadFile(temporaryTimingsPath(job.Reciter, job.Slug, job.VerseKey))
+	if err != nil {
+		return err
+	}
+
+	currentData, err := storage.ReadFile(ctx, currentPath)
+	switch {
+	case err == nil:
+		err = storage.WriteFile(ctx, previousPath, currentData)
+		if err != nil {
+			return err
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	return storage.WriteFile(ctx, currentPath, jsonData)
+}
+
+func recordRevision(job sqlc.LafzizeJob) error {
+	jsonData, err := storage.ReadFile(context.Background(), timingsPath(job.Reciter, job.Slug, job.VerseKey))
+	if err != nil {
+		return err
+	}
+
+	var timing models.Timing
+	err = json.Unmarshal(jsonData, &timing)
+	if err != nil {
+		return err
+	}
+
+	_, err = revisions.Record(context.Background(), db.Queries, job.Reciter, job.Slug, job.VerseKey, job.Reciter, revisions.SourceLafzize, timing)
+	return err
+}
+
+func audioPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, fmt.Sprintf("%s.mp3", verseKey))
+}
+
+func timingsPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, fmt.Sprintf("%s.json", verseKey))
+}
+
+func temporaryTimingsPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, fmt.Sprintf("%s.json.tmp", verseKey))
+}
+
+// PreviousTimingsPath is where the timings replaced by the latest successful
+// lafzize job of a recitation file are kept.
+func PreviousTimingsPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, fmt.Sprintf("%s.previous.json", verseKey))
+}
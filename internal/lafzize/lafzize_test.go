@@ -0,0 +1,229 @@
+package lafzize
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/aligner"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// setup runs the test in a fresh data directory with a migrated database
+// holding a transcoded recitation file of 1:1.
+func setup(t *testing.T) {
+	t.Helper()
+
+	migrations, err := filepath.Glob(filepath.Join("..", "db", "migrations", "*.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(migrations)
+	for i, migration := range migrations {
+		migrations[i], err = filepath.Abs(migration)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(workingDirectory)
+	})
+
+	err = os.MkdirAll(filepath.Join("data", "uploads", "ali", "r1"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.Connect()
+	t.Cleanup(func() {
+		db.DB.Close()
+	})
+	for _, migration := range migrations {
+		query, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = db.DB.Exec(string(query))
+		if err != nil {
+			t.Fatalf("Error applying %s: %v", filepath.Base(migration), err)
+		}
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO users(username, password, displayname) VALUES ('ali', '', 'ali');
+		INSERT INTO recitations(reciter, slug, name) VALUES ('ali', 'r1', 'r1');
+		INSERT INTO recitation_files(reciter, slug, verse_key, transcode_status) VALUES ('ali', 'r1', '1:1', 'done');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(audioPath("ali", "r1", "1:1"), []byte("audio"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.Backend = storage.NewLocal(filepath.Join("data", "uploads"), filepath.Join("data", "blobs"))
+	viper.Set("lafzize_retries", 0)
+	viper.Set("lafzize_timeout", "1m")
+}
+
+func TestLafzizeWithFakeAligner(t *testing.T) {
+	setup(t)
+
+	words := []string{"بِسْمِ", "ٱللَّهِ", "ٱلرَّحْمَٰنِ", "ٱلرَّحِيمِ"}
+	backend = &aligner.Fake{
+		Duration: func(audioPath string) (float64, error) {
+			return 6, nil
+		},
+		Words: func(verseKey string) []string {
+			return words
+		},
+	}
+
+	ctx := context.Background()
+	_, err := Schedule(ctx, "ali", "r1", "1:1", sql.NullInt64{})
+	if err != nil {
+		t.Fatalf("Error scheduling: %v", err)
+	}
+
+	_, err = Schedule(ctx, "ali", "r1", "1:1", sql.NullInt64{})
+	if err != ErrProcessing {
+		t.Fatalf("Expected scheduling twice to fail with %v, got %v", ErrProcessing, err)
+	}
+
+	job, err := db.Queries.LafzizeJobClaimLafzizeJob(ctx)
+	if err != nil {
+		t.Fatalf("Error claiming job: %v", err)
+	}
+	process(ctx, job)
+
+	job, err = db.Queries.LafzizeJobSelectLafzizeJob(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != StateSucceeded {
+		t.Fatalf("Expected job to have %s, got %s: %s", StateSucceeded, job.State, job.Error)
+	}
+
+	recitationFile, err := db.Queries.RecitationFileSelectRecitationFile(ctx, sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  "ali",
+		Slug:     "r1",
+		VerseKey: "1:1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recitationFile.HasTimings || recitationFile.LafzizeProcessing {
+		t.Fatalf("Expected timings and no lafzize processing, got has_timings %t and lafzize_processing %t", recitationFile.HasTimings, recitationFile.LafzizeProcessing)
+	}
+
+	jsonData, err := os.ReadFile(timingsPath("ali", "r1", "1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var timing models.Timing
+	err = json.Unmarshal(jsonData, &timing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(timing.Segments) != len(words) {
+		t.Fatalf("Expected %d segments, got %d", len(words), len(timing.Segments))
+	}
+	for i, segment := range timing.Segments {
+		if segment.Text != words[i] {
+			t.Errorf("Expected segment %d to be %q, got %q", i, words[i], segment.Text)
+		}
+	}
+	if end := timing.Segments[len(words)-1].End; end != 6 {
+		t.Errorf("Expected the last segment to end with the audio at 6, got %v", end)
+	}
+}
+
+// remote is a backend that is not local, storing objects in a directory, so
+// that data/uploads is only a cache of it.
+type remote struct {
+	*storage.Local
+}
+
+func TestLafzizeWithRemoteStorage(t *testing.T) {
+	setup(t)
+
+	// The audio is stored as a blob, so nothing is fetched into the
+	// directory of the recitation.
+	err := os.RemoveAll(filepath.Join("data", "uploads", "ali"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll("remote-blobs", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join("remote-blobs", "blob.mp3"), []byte("audio"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.Backend = remote{storage.NewLocal("remote", "remote-blobs")}
+	storage.Resolve = func(localPath string) (string, bool) {
+		if localPath == audioPath("ali", "r1", "1:1") {
+			return filepath.Join("data", "blobs", "blob.mp3"), true
+		}
+		return "", false
+	}
+	t.Cleanup(func() {
+		storage.Resolve = nil
+	})
+
+	backend = &aligner.Fake{
+		Duration: func(audioPath string) (float64, error) {
+			return 6, nil
+		},
+		Words: func(verseKey string) []string {
+			return []string{"w"}
+		},
+	}
+
+	ctx := context.Background()
+	_, err = Schedule(ctx, "ali", "r1", "1:1", sql.NullInt64{})
+	if err != nil {
+		t.Fatalf("Error scheduling: %v", err)
+	}
+
+	job, err := db.Queries.LafzizeJobClaimLafzizeJob(ctx)
+	if err != nil {
+		t.Fatalf("Error claiming job: %v", err)
+	}
+	process(ctx, job)
+
+	job, err = db.Queries.LafzizeJobSelectLafzizeJob(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != StateSucceeded {
+		t.Fatalf("Expected job to have %s, got %s: %s", StateSucceeded, job.State, job.Error)
+	}
+
+	_, err = os.Stat(filepath.Join("remote", "ali", "r1", "1:1.json"))
+	if err != nil {
+		t.Fatalf("Expected the timings to be stored: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/render"
+	"github.com/spf13/viper"
+)
+
+// Admin rejects requests from users not listed in the admins config. It must
+// be used after Auth.
+func Admin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username := r.Context().Value("username").(string)
+
+		if !slices.Contains(viper.GetStringSlice("admins"), username) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, render.M{
+				"message": "Only admins are allowed to do this",
+				"error":   "",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
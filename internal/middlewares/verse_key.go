@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/validators"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// VerseKey rejects requests whose verse_key URL parameter does not refer to
+// an existing verse. Routes without the parameter are passed through.
+func VerseKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verseKey := chi.URLParam(r, "verse_key")
+		if verseKey == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err := validators.ValidateVerseKey(verseKey)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, render.M{
+				"message": "Invalid verse key",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ArchiveManifest is written to manifest.json at the root of recitation
+// archives.
+type ArchiveManifest struct {
+	Recitation ArchiveRecitation `json:"recitation"`
+	Files      []ArchiveFile     `json:"files"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type ArchiveRecitation struct {
+	Reciter string `json:"reciter"`
+	Slug    string `json:"slug"`
+	Name    string `json:"name"`
+}
+
+type ArchiveFile struct {
+	VerseKey   string `json:"verse_key"`
+	Audio      string `json:"audio"`
+	AudioSize  int64  `json:"audio_size"`
+	Timings    string `json:"timings,omitempty"`
+	HasTimings bool   `json:"has_timings"`
+}
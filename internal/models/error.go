@@ -4,3 +4,17 @@ type Error struct {
 	Message string `json:"message"`
 	Error   string `json:"error"`
 }
+
+type TimingValidationError struct {
+	Message string         `json:"message"`
+	Error   string         `json:"error"`
+	Errors  []SegmentError `json:"errors"`
+}
+
+// SegmentError describes a problem with a segment of a timing. Segment is -1
+// for problems with the timing as a whole.
+type SegmentError struct {
+	Segment int    `json:"segment"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
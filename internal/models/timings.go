@@ -11,3 +11,34 @@ type Segment struct {
 	Text  string  `json:"text,omitempty"`
 	Score float64 `json:"score,omitempty"`
 }
+
+const (
+	SegmentUnchanged = "unchanged"
+	SegmentChanged   = "changed"
+	SegmentAdded     = "added"
+	SegmentRemoved   = "removed"
+)
+
+type SegmentDiff struct {
+	Index      int      `json:"index"`
+	Status     string   `json:"status"`
+	StartDelta float64  `json:"start_delta"`
+	EndDelta   float64  `json:"end_delta"`
+	Old        *Segment `json:"old,omitempty"`
+	New        *Segment `json:"new,omitempty"`
+}
+
+// ChapterTiming holds the timings of the verses of a chapter, offset into the
+// concatenated audio of the chapter.
+type ChapterTiming struct {
+	Chapter int           `json:"chapter"`
+	Basmala *VerseTiming  `json:"basmala"`
+	Verses  []VerseTiming `json:"verses"`
+}
+
+type VerseTiming struct {
+	VerseKey string    `json:"verse_key"`
+	Start    float64   `json:"start"`
+	End      float64   `json:"end"`
+	Segments []Segment `json:"segments"`
+}
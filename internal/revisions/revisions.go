@@ -0,0 +1,68 @@
+package revisions
+
+import (
+	"context"
+	"encoding/json"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+)
+
+const (
+	SourceManual  = "manual"
+	SourceLafzize = "lafzize"
+	SourceImport  = "import"
+	SourceRestore = "restore"
+	SourceSplit   = "split"
+)
+
+// Record stores a version of the timings of a recitation file, using the
+// given queries so that it can be part of a transaction.
+func Record(ctx context.Context, queries *sqlc.Queries, reciter string, slug string, verseKey string, author string, source string, timing models.Timing) (sqlc.TimingRevision, error) {
+	jsonData, err := json.Marshal(timing)
+	if err != nil {
+		return sqlc.TimingRevision{}, err
+	}
+
+	return queries.TimingRevisionCreateTimingRevision(ctx, sqlc.TimingRevisionCreateTimingRevisionParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+		Author:   author,
+		Source:   source,
+		Timing:   string(jsonData),
+	})
+}
+
+// Diff compares two timings segment by segment, reporting how much the start
+// and end of each segment moved from a to b.
+func Diff(a models.Timing, b models.Timing) []models.SegmentDiff {
+	diffs := []models.SegmentDiff{}
+
+	for i := range max(len(a.Segments), len(b.Segments)) {
+		diff := models.SegmentDiff{Index: i}
+
+		switch {
+		case i >= len(a.Segments):
+			diff.Status = models.SegmentAdded
+			diff.New = &b.Segments[i]
+		case i >= len(b.Segments):
+			diff.Status = models.SegmentRemoved
+			diff.Old = &a.Segments[i]
+		default:
+			diff.Old = &a.Segments[i]
+			diff.New = &b.Segments[i]
+			diff.StartDelta = b.Segments[i].Start - a.Segments[i].Start
+			diff.EndDelta = b.Segments[i].End - a.Segments[i].End
+
+			diff.Status = models.SegmentUnchanged
+			if *diff.Old != *diff.New {
+				diff.Status = models.SegmentChanged
+			}
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
@@ -4,18 +4,74 @@
 
 package sqlc
 
+import (
+	"database/sql"
+	"time"
+)
+
+type Blob struct {
+	Hash      string    `json:"hash"`
+	Extension string    `json:"extension"`
+	Size      int64     `json:"size"`
+	RefCount  int64     `json:"ref_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LafzizeBatch struct {
+	ID        int64     `json:"id"`
+	Reciter   string    `json:"reciter"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LafzizeJob struct {
+	ID        int64         `json:"id"`
+	Reciter   string        `json:"reciter"`
+	Slug      string        `json:"slug"`
+	VerseKey  string        `json:"verse_key"`
+	State     string        `json:"state"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Attempts  int64         `json:"attempts"`
+	Error     string        `json:"error"`
+	BatchID   sql.NullInt64 `json:"batch_id"`
+}
+
 type Recitation struct {
-	Slug    string `json:"slug"`
-	Name    string `json:"name"`
-	Reciter string `json:"reciter"`
+	Slug        string       `json:"slug"`
+	Name        string       `json:"name"`
+	Reciter     string       `json:"reciter"`
+	AutoLafzize sql.NullBool `json:"auto_lafzize"`
 }
 
 type RecitationFile struct {
-	Reciter           string `json:"reciter"`
-	Slug              string `json:"slug"`
-	VerseKey          string `json:"verse_key"`
-	HasTimings        bool   `json:"has_timings"`
-	LafzizeProcessing bool   `json:"lafzize_processing"`
+	Reciter           string  `json:"reciter"`
+	Slug              string  `json:"slug"`
+	VerseKey          string  `json:"verse_key"`
+	HasTimings        bool    `json:"has_timings"`
+	LafzizeProcessing bool    `json:"lafzize_processing"`
+	LafzizeError      string  `json:"lafzize_error"`
+	Duration          float64 `json:"duration"`
+	BitRate           int64   `json:"bit_rate"`
+	SampleRate        int64   `json:"sample_rate"`
+	Channels          int64   `json:"channels"`
+	Size              int64   `json:"size"`
+	TranscodeStatus   string  `json:"transcode_status"`
+	TranscodeError    string  `json:"transcode_error"`
+}
+
+type RecitationFileBlob struct {
+	Reciter   string `json:"reciter"`
+	Slug      string `json:"slug"`
+	VerseKey  string `json:"verse_key"`
+	Extension string `json:"extension"`
+	Hash      string `json:"hash"`
+}
+
+type RecitationID struct {
+	ID      int64  `json:"id"`
+	Reciter string `json:"reciter"`
+	Slug    string `json:"slug"`
 }
 
 type Session struct {
@@ -24,6 +80,17 @@ type Session struct {
 	Username     string `json:"username"`
 }
 
+type TimingRevision struct {
+	ID        int64     `json:"id"`
+	Reciter   string    `json:"reciter"`
+	Slug      string    `json:"slug"`
+	VerseKey  string    `json:"verse_key"`
+	Author    string    `json:"author"`
+	Source    string    `json:"source"`
+	Timing    string    `json:"timing"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type User struct {
 	Username    string `json:"username"`
 	Password    string `json:"password"`
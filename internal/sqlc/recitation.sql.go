@@ -7,12 +7,13 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
 )
 
 const recitationCreateRecitation = `-- name: RecitationCreateRecitation :one
 INSERT INTO recitations(reciter, slug, name)
 	VALUES (?1, ?2, ?3)
-RETURNING slug, name, reciter
+RETURNING slug, name, reciter, auto_lafzize
 `
 
 type RecitationCreateRecitationParams struct {
@@ -24,7 +25,12 @@ type RecitationCreateRecitationParams struct {
 func (q *Queries) RecitationCreateRecitation(ctx context.Context, arg RecitationCreateRecitationParams) (Recitation, error) {
 	row := q.db.QueryRowContext(ctx, recitationCreateRecitation, arg.Reciter, arg.Slug, arg.Name)
 	var i Recitation
-	err := row.Scan(&i.Slug, &i.Name, &i.Reciter)
+	err := row.Scan(
+		&i.Slug,
+		&i.Name,
+		&i.Reciter,
+		&i.AutoLafzize,
+	)
 	return i, err
 }
 
@@ -32,7 +38,7 @@ const recitationDeleteRecitation = `-- name: RecitationDeleteRecitation :one
 DELETE FROM recitations
 WHERE
 	reciter = ?1 AND slug = ?2
-RETURNING slug, name, reciter
+RETURNING slug, name, reciter, auto_lafzize
 `
 
 type RecitationDeleteRecitationParams struct {
@@ -43,13 +49,18 @@ type RecitationDeleteRecitationParams struct {
 func (q *Queries) RecitationDeleteRecitation(ctx context.Context, arg RecitationDeleteRecitationParams) (Recitation, error) {
 	row := q.db.QueryRowContext(ctx, recitationDeleteRecitation, arg.Reciter, arg.Slug)
 	var i Recitation
-	err := row.Scan(&i.Slug, &i.Name, &i.Reciter)
+	err := row.Scan(
+		&i.Slug,
+		&i.Name,
+		&i.Reciter,
+		&i.AutoLafzize,
+	)
 	return i, err
 }
 
 const recitationSelectRecitation = `-- name: RecitationSelectRecitation :one
 SELECT
-	slug, name, reciter
+	slug, name, reciter, auto_lafzize
 FROM
     recitations
 WHERE
@@ -64,13 +75,18 @@ type RecitationSelectRecitationParams struct {
 func (q *Queries) RecitationSelectRecitation(ctx context.Context, arg RecitationSelectRecitationParams) (Recitation, error) {
 	row := q.db.QueryRowContext(ctx, recitationSelectRecitation, arg.Reciter, arg.Slug)
 	var i Recitation
-	err := row.Scan(&i.Slug, &i.Name, &i.Reciter)
+	err := row.Scan(
+		&i.Slug,
+		&i.Name,
+		&i.Reciter,
+		&i.AutoLafzize,
+	)
 	return i, err
 }
 
 const recitationSelectRecitations = `-- name: RecitationSelectRecitations :many
 SELECT
-	slug, name, reciter
+	slug, name, reciter, auto_lafzize
 FROM
     recitations
 `
@@ -84,7 +100,12 @@ func (q *Queries) RecitationSelectRecitations(ctx context.Context) ([]Recitation
 	items := []Recitation{}
 	for rows.Next() {
 		var i Recitation
-		if err := rows.Scan(&i.Slug, &i.Name, &i.Reciter); err != nil {
+		if err := rows.Scan(
+			&i.Slug,
+			&i.Name,
+			&i.Reciter,
+			&i.AutoLafzize,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -101,21 +122,33 @@ func (q *Queries) RecitationSelectRecitations(ctx context.Context) ([]Recitation
 const recitationUpdateRecitation = `-- name: RecitationUpdateRecitation :one
 UPDATE recitations
 SET
-	name = ?3
+	name = ?3,
+	auto_lafzize = ?4
 WHERE
 	reciter = ?1 AND slug = ?2
-RETURNING slug, name, reciter
+RETURNING slug, name, reciter, auto_lafzize
 `
 
 type RecitationUpdateRecitationParams struct {
-	Reciter string `json:"reciter"`
-	Slug    string `json:"slug"`
-	Name    string `json:"name"`
+	Reciter     string       `json:"reciter"`
+	Slug        string       `json:"slug"`
+	Name        string       `json:"name"`
+	AutoLafzize sql.NullBool `json:"auto_lafzize"`
 }
 
 func (q *Queries) RecitationUpdateRecitation(ctx context.Context, arg RecitationUpdateRecitationParams) (Recitation, error) {
-	row := q.db.QueryRowContext(ctx, recitationUpdateRecitation, arg.Reciter, arg.Slug, arg.Name)
+	row := q.db.QueryRowContext(ctx, recitationUpdateRecitation,
+		arg.Reciter,
+		arg.Slug,
+		arg.Name,
+		arg.AutoLafzize,
+	)
 	var i Recitation
-	err := row.Scan(&i.Slug, &i.Name, &i.Reciter)
+	err := row.Scan(
+		&i.Slug,
+		&i.Name,
+		&i.Reciter,
+		&i.AutoLafzize,
+	)
 	return i, err
 }
@@ -9,10 +9,49 @@ import (
 	"context"
 )
 
+const recitationFileClaimTranscode = `-- name: RecitationFileClaimTranscode :one
+UPDATE recitation_files
+SET
+	transcode_status = 'running'
+WHERE
+	rowid = (
+		SELECT
+			rowid
+		FROM
+			recitation_files
+		WHERE
+			transcode_status = 'pending'
+		ORDER BY
+			rowid
+		LIMIT 1)
+RETURNING reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
+`
+
+func (q *Queries) RecitationFileClaimTranscode(ctx context.Context) (RecitationFile, error) {
+	row := q.db.QueryRowContext(ctx, recitationFileClaimTranscode)
+	var i RecitationFile
+	err := row.Scan(
+		&i.Reciter,
+		&i.Slug,
+		&i.VerseKey,
+		&i.HasTimings,
+		&i.LafzizeProcessing,
+		&i.LafzizeError,
+		&i.Duration,
+		&i.BitRate,
+		&i.SampleRate,
+		&i.Channels,
+		&i.Size,
+		&i.TranscodeStatus,
+		&i.TranscodeError,
+	)
+	return i, err
+}
+
 const recitationFileCreateRecitationFile = `-- name: RecitationFileCreateRecitationFile :one
-INSERT INTO recitation_files(reciter, slug, verse_key)
-	VALUES (?1, ?2, ?3)
-RETURNING reciter, slug, verse_key, has_timings, lafzize_processing
+INSERT INTO recitation_files(reciter, slug, verse_key, transcode_status)
+	VALUES (?1, ?2, ?3, 'uploading')
+RETURNING reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
 `
 
 type RecitationFileCreateRecitationFileParams struct {
@@ -30,6 +69,14 @@ func (q *Queries) RecitationFileCreateRecitationFile(ctx context.Context, arg Re
 		&i.VerseKey,
 		&i.HasTimings,
 		&i.LafzizeProcessing,
+		&i.LafzizeError,
+		&i.Duration,
+		&i.BitRate,
+		&i.SampleRate,
+		&i.Channels,
+		&i.Size,
+		&i.TranscodeStatus,
+		&i.TranscodeError,
 	)
 	return i, err
 }
@@ -38,7 +85,7 @@ const recitationFileDeleteRecitationFile = `-- name: RecitationFileDeleteRecitat
 DELETE FROM recitation_files
 WHERE
 	reciter = ?1 AND slug = ?2 AND verse_key = ?3
-RETURNING reciter, slug, verse_key, has_timings, lafzize_processing
+RETURNING reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
 `
 
 type RecitationFileDeleteRecitationFileParams struct {
@@ -56,13 +103,190 @@ func (q *Queries) RecitationFileDeleteRecitationFile(ctx context.Context, arg Re
 		&i.VerseKey,
 		&i.HasTimings,
 		&i.LafzizeProcessing,
+		&i.LafzizeError,
+		&i.Duration,
+		&i.BitRate,
+		&i.SampleRate,
+		&i.Channels,
+		&i.Size,
+		&i.TranscodeStatus,
+		&i.TranscodeError,
 	)
 	return i, err
 }
 
+const recitationFileFailInterruptedUploads = `-- name: RecitationFileFailInterruptedUploads :exec
+UPDATE recitation_files
+SET
+	transcode_status = 'failed',
+	transcode_error = 'the upload was interrupted'
+WHERE
+	transcode_status = 'uploading'
+`
+
+func (q *Queries) RecitationFileFailInterruptedUploads(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, recitationFileFailInterruptedUploads)
+	return err
+}
+
+const recitationFileMarkLafzizeProcessing = `-- name: RecitationFileMarkLafzizeProcessing :execrows
+UPDATE recitation_files
+SET
+	lafzize_processing = 1,
+	lafzize_error = ''
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND lafzize_processing = 0
+`
+
+type RecitationFileMarkLafzizeProcessingParams struct {
+	Reciter  string `json:"reciter"`
+	Slug     string `json:"slug"`
+	VerseKey string `json:"verse_key"`
+}
+
+func (q *Queries) RecitationFileMarkLafzizeProcessing(ctx context.Context, arg RecitationFileMarkLafzizeProcessingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recitationFileMarkLafzizeProcessing, arg.Reciter, arg.Slug, arg.VerseKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recitationFileRequeueFailedTranscode = `-- name: RecitationFileRequeueFailedTranscode :execrows
+UPDATE recitation_files
+SET
+	transcode_status = 'pending',
+	transcode_error = ''
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND transcode_status = 'failed'
+`
+
+type RecitationFileRequeueFailedTranscodeParams struct {
+	Reciter  string `json:"reciter"`
+	Slug     string `json:"slug"`
+	VerseKey string `json:"verse_key"`
+}
+
+func (q *Queries) RecitationFileRequeueFailedTranscode(ctx context.Context, arg RecitationFileRequeueFailedTranscodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recitationFileRequeueFailedTranscode, arg.Reciter, arg.Slug, arg.VerseKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recitationFileRequeueRunningTranscodes = `-- name: RecitationFileRequeueRunningTranscodes :exec
+UPDATE recitation_files
+SET
+	transcode_status = 'pending'
+WHERE
+	transcode_status = 'running'
+`
+
+func (q *Queries) RecitationFileRequeueRunningTranscodes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, recitationFileRequeueRunningTranscodes)
+	return err
+}
+
+const recitationFileResetLafzizeProcessing = `-- name: RecitationFileResetLafzizeProcessing :exec
+UPDATE recitation_files
+SET
+	lafzize_processing = 0
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3 AND lafzize_processing = 1 AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			lafzize_jobs
+		WHERE
+			lafzize_jobs.reciter = recitation_files.reciter
+			AND lafzize_jobs.slug = recitation_files.slug
+			AND lafzize_jobs.verse_key = recitation_files.verse_key
+			AND lafzize_jobs.state IN ('queued', 'running'))
+`
+
+type RecitationFileResetLafzizeProcessingParams struct {
+	Reciter  string `json:"reciter"`
+	Slug     string `json:"slug"`
+	VerseKey string `json:"verse_key"`
+}
+
+func (q *Queries) RecitationFileResetLafzizeProcessing(ctx context.Context, arg RecitationFileResetLafzizeProcessingParams) error {
+	_, err := q.db.ExecContext(ctx, recitationFileResetLafzizeProcessing, arg.Reciter, arg.Slug, arg.VerseKey)
+	return err
+}
+
+const recitationFileResetStaleLafzizeProcessing = `-- name: RecitationFileResetStaleLafzizeProcessing :exec
+UPDATE recitation_files
+SET
+	lafzize_processing = 0
+WHERE
+	lafzize_processing = 1 AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			lafzize_jobs
+		WHERE
+			lafzize_jobs.reciter = recitation_files.reciter
+			AND lafzize_jobs.slug = recitation_files.slug
+			AND lafzize_jobs.verse_key = recitation_files.verse_key
+			AND lafzize_jobs.state IN ('queued', 'running'))
+`
+
+func (q *Queries) RecitationFileResetStaleLafzizeProcessing(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, recitationFileResetStaleLafzizeProcessing)
+	return err
+}
+
+const recitationFileSelectAllRecitationFiles = `-- name: RecitationFileSelectAllRecitationFiles :many
+SELECT
+	reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
+FROM
+	recitation_files
+ORDER BY
+	reciter, slug, verse_key
+`
+
+func (q *Queries) RecitationFileSelectAllRecitationFiles(ctx context.Context) ([]RecitationFile, error) {
+	rows, err := q.db.QueryContext(ctx, recitationFileSelectAllRecitationFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecitationFile{}
+	for rows.Next() {
+		var i RecitationFile
+		if err := rows.Scan(
+			&i.Reciter,
+			&i.Slug,
+			&i.VerseKey,
+			&i.HasTimings,
+			&i.LafzizeProcessing,
+			&i.LafzizeError,
+			&i.Duration,
+			&i.BitRate,
+			&i.SampleRate,
+			&i.Channels,
+			&i.Size,
+			&i.TranscodeStatus,
+			&i.TranscodeError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const recitationFileSelectRecitationFile = `-- name: RecitationFileSelectRecitationFile :one
 SELECT
-	reciter, slug, verse_key, has_timings, lafzize_processing
+	reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
 FROM
     recitation_files
 WHERE
@@ -84,13 +308,21 @@ func (q *Queries) RecitationFileSelectRecitationFile(ctx context.Context, arg Re
 		&i.VerseKey,
 		&i.HasTimings,
 		&i.LafzizeProcessing,
+		&i.LafzizeError,
+		&i.Duration,
+		&i.BitRate,
+		&i.SampleRate,
+		&i.Channels,
+		&i.Size,
+		&i.TranscodeStatus,
+		&i.TranscodeError,
 	)
 	return i, err
 }
 
 const recitationFileSelectRecitationFiles = `-- name: RecitationFileSelectRecitationFiles :many
 SELECT
-	reciter, slug, verse_key, has_timings, lafzize_processing
+	reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
 FROM
     recitation_files
 WHERE
@@ -117,6 +349,69 @@ func (q *Queries) RecitationFileSelectRecitationFiles(ctx context.Context, arg R
 			&i.VerseKey,
 			&i.HasTimings,
 			&i.LafzizeProcessing,
+			&i.LafzizeError,
+			&i.Duration,
+			&i.BitRate,
+			&i.SampleRate,
+			&i.Channels,
+			&i.Size,
+			&i.TranscodeStatus,
+			&i.TranscodeError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recitationFileSelectRecitationFilesWithoutBlobs = `-- name: RecitationFileSelectRecitationFilesWithoutBlobs :many
+SELECT
+	reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
+FROM
+	recitation_files
+WHERE
+	transcode_status = 'done'
+	AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			recitation_file_blobs
+		WHERE
+			recitation_file_blobs.reciter = recitation_files.reciter
+			AND recitation_file_blobs.slug = recitation_files.slug
+			AND recitation_file_blobs.verse_key = recitation_files.verse_key)
+`
+
+func (q *Queries) RecitationFileSelectRecitationFilesWithoutBlobs(ctx context.Context) ([]RecitationFile, error) {
+	rows, err := q.db.QueryContext(ctx, recitationFileSelectRecitationFilesWithoutBlobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecitationFile{}
+	for rows.Next() {
+		var i RecitationFile
+		if err := rows.Scan(
+			&i.Reciter,
+			&i.Slug,
+			&i.VerseKey,
+			&i.HasTimings,
+			&i.LafzizeProcessing,
+			&i.LafzizeError,
+			&i.Duration,
+			&i.BitRate,
+			&i.SampleRate,
+			&i.Channels,
+			&i.Size,
+			&i.TranscodeStatus,
+			&i.TranscodeError,
 		); err != nil {
 			return nil, err
 		}
@@ -131,6 +426,123 @@ func (q *Queries) RecitationFileSelectRecitationFiles(ctx context.Context, arg R
 	return items, nil
 }
 
+const recitationFileSelectStaleLafzizeProcessing = `-- name: RecitationFileSelectStaleLafzizeProcessing :many
+SELECT
+	reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
+FROM
+	recitation_files
+WHERE
+	lafzize_processing = 1 AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			lafzize_jobs
+		WHERE
+			lafzize_jobs.reciter = recitation_files.reciter
+			AND lafzize_jobs.slug = recitation_files.slug
+			AND lafzize_jobs.verse_key = recitation_files.verse_key
+			AND lafzize_jobs.state IN ('queued', 'running'))
+`
+
+func (q *Queries) RecitationFileSelectStaleLafzizeProcessing(ctx context.Context) ([]RecitationFile, error) {
+	rows, err := q.db.QueryContext(ctx, recitationFileSelectStaleLafzizeProcessing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecitationFile{}
+	for rows.Next() {
+		var i RecitationFile
+		if err := rows.Scan(
+			&i.Reciter,
+			&i.Slug,
+			&i.VerseKey,
+			&i.HasTimings,
+			&i.LafzizeProcessing,
+			&i.LafzizeError,
+			&i.Duration,
+			&i.BitRate,
+			&i.SampleRate,
+			&i.Channels,
+			&i.Size,
+			&i.TranscodeStatus,
+			&i.TranscodeError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recitationFileUpdateLafzizeError = `-- name: RecitationFileUpdateLafzizeError :exec
+UPDATE recitation_files
+SET
+	lafzize_error = ?4
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3
+`
+
+type RecitationFileUpdateLafzizeErrorParams struct {
+	Reciter      string `json:"reciter"`
+	Slug         string `json:"slug"`
+	VerseKey     string `json:"verse_key"`
+	LafzizeError string `json:"lafzize_error"`
+}
+
+func (q *Queries) RecitationFileUpdateLafzizeError(ctx context.Context, arg RecitationFileUpdateLafzizeErrorParams) error {
+	_, err := q.db.ExecContext(ctx, recitationFileUpdateLafzizeError,
+		arg.Reciter,
+		arg.Slug,
+		arg.VerseKey,
+		arg.LafzizeError,
+	)
+	return err
+}
+
+const recitationFileUpdateMetadata = `-- name: RecitationFileUpdateMetadata :exec
+UPDATE recitation_files
+SET
+	duration = ?4,
+	bit_rate = ?5,
+	sample_rate = ?6,
+	channels = ?7,
+	size = ?8
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3
+`
+
+type RecitationFileUpdateMetadataParams struct {
+	Reciter    string  `json:"reciter"`
+	Slug       string  `json:"slug"`
+	VerseKey   string  `json:"verse_key"`
+	Duration   float64 `json:"duration"`
+	BitRate    int64   `json:"bit_rate"`
+	SampleRate int64   `json:"sample_rate"`
+	Channels   int64   `json:"channels"`
+	Size       int64   `json:"size"`
+}
+
+func (q *Queries) RecitationFileUpdateMetadata(ctx context.Context, arg RecitationFileUpdateMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, recitationFileUpdateMetadata,
+		arg.Reciter,
+		arg.Slug,
+		arg.VerseKey,
+		arg.Duration,
+		arg.BitRate,
+		arg.SampleRate,
+		arg.Channels,
+		arg.Size,
+	)
+	return err
+}
+
 const recitationFileUpdateRecitationFile = `-- name: RecitationFileUpdateRecitationFile :one
 UPDATE recitation_files
 SET
@@ -138,7 +550,7 @@ SET
 	lafzize_processing = ?5
 WHERE
 	reciter = ?1 AND slug = ?2 AND verse_key = ?3
-RETURNING reciter, slug, verse_key, has_timings, lafzize_processing
+RETURNING reciter, slug, verse_key, has_timings, lafzize_processing, lafzize_error, duration, bit_rate, sample_rate, channels, size, transcode_status, transcode_error
 `
 
 type RecitationFileUpdateRecitationFileParams struct {
@@ -164,6 +576,42 @@ func (q *Queries) RecitationFileUpdateRecitationFile(ctx context.Context, arg Re
 		&i.VerseKey,
 		&i.HasTimings,
 		&i.LafzizeProcessing,
+		&i.LafzizeError,
+		&i.Duration,
+		&i.BitRate,
+		&i.SampleRate,
+		&i.Channels,
+		&i.Size,
+		&i.TranscodeStatus,
+		&i.TranscodeError,
 	)
 	return i, err
 }
+
+const recitationFileUpdateTranscodeStatus = `-- name: RecitationFileUpdateTranscodeStatus :exec
+UPDATE recitation_files
+SET
+	transcode_status = ?4,
+	transcode_error = ?5
+WHERE
+	reciter = ?1 AND slug = ?2 AND verse_key = ?3
+`
+
+type RecitationFileUpdateTranscodeStatusParams struct {
+	Reciter         string `json:"reciter"`
+	Slug            string `json:"slug"`
+	VerseKey        string `json:"verse_key"`
+	TranscodeStatus string `json:"transcode_status"`
+	TranscodeError  string `json:"transcode_error"`
+}
+
+func (q *Queries) RecitationFileUpdateTranscodeStatus(ctx context.Context, arg RecitationFileUpdateTranscodeStatusParams) error {
+	_, err := q.db.ExecContext(ctx, recitationFileUpdateTranscodeStatus,
+		arg.Reciter,
+		arg.Slug,
+		arg.VerseKey,
+		arg.TranscodeStatus,
+		arg.TranscodeError,
+	)
+	return err
+}
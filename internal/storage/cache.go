@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// With a remote backend, data/uploads and data/blobs are a cache: files are
+// fetched into them when a program needs them and written to them before they
+// are stored. Once they are larger than storage_cache_size, the least recently
+// used files are evicted. Only files known to be stored are evicted, so that
+// files that failed to be stored are kept until Sync stores them.
+
+// Files used more recently than this are not evicted, as they may be in use.
+const evictionGrace = time.Minute
+
+// Metadata of objects is cached for this long, as chapters look up every one
+// of their verses.
+const statExpiry = time.Minute
+
+// cacheMutex guards stored, used and stats, and is held while a file is
+// evicted so that it is not handed out at the same time.
+var cacheMutex sync.Mutex
+
+// stored holds the local files known to be stored in the backend.
+var stored = map[string]bool{}
+
+// used holds when local files were last used, falling back to their
+// modification time.
+var used = map[string]time.Time{}
+
+type cachedStat struct {
+	object  Object
+	err     error
+	expires time.Time
+}
+
+// stats caches the metadata of objects by key.
+var stats = map[string]cachedStat{}
+
+// trimming holds a token while the cache is trimmed.
+var trimming = make(chan struct{}, 1)
+
+func markStored(localPath string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	localPath = filepath.Clean(localPath)
+	stored[localPath] = true
+	used[localPath] = time.Now()
+}
+
+func markUsed(localPath string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	used[filepath.Clean(localPath)] = time.Now()
+}
+
+// useCached reports whether a local file exists, marking it as used so that
+// it is not evicted while it is handed out.
+func useCached(localPath string) bool {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	fileInfo, err := os.Stat(localPath)
+	if err != nil || fileInfo.IsDir() {
+		return false
+	}
+	used[filepath.Clean(localPath)] = time.Now()
+	return true
+}
+
+// forget drops what is known about a local file or directory once it is
+// deleted.
+func forget(localPath string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	localPath = filepath.Clean(localPath)
+	for path := range stored {
+		if path == localPath || strings.HasPrefix(path, localPath+string(filepath.Separator)) {
+			delete(stored, path)
+		}
+	}
+	for path := range used {
+		if path == localPath || strings.HasPrefix(path, localPath+string(filepath.Separator)) {
+			delete(used, path)
+		}
+	}
+}
+
+// stat returns the metadata of an object, cached with a remote backend.
+func stat(ctx context.Context, key string) (Object, error) {
+	if IsLocal() {
+		return Backend.Stat(ctx, key)
+	}
+
+	cacheMutex.Lock()
+	cached, ok := stats[key]
+	cacheMutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.object, cached.err
+	}
+
+	object, err := Backend.Stat(ctx, key)
+	if err == nil || errors.Is(err, ErrNotExist) {
+		cacheMutex.Lock()
+		stats[key] = cachedStat{object: object, err: err, expires: time.Now().Add(statExpiry)}
+		cacheMutex.Unlock()
+	}
+	return object, err
+}
+
+// forgetStat drops the cached metadata of an object, or of every object under
+// it, once it changed.
+func forgetStat(key string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	for cachedKey := range stats {
+		if cachedKey == key || strings.HasPrefix(cachedKey, key+"/") {
+			delete(stats, cachedKey)
+		}
+	}
+}
+
+// download fetches an object into the cache. The file gets the modification
+// time of the object, so that Sync does not store it again.
+func download(ctx context.Context, key string, localPath string) error {
+	object, err := stat(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	content, err := Backend.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	err = os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(localPath), filepath.Base(localPath)+".tmp.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chtimes(file.Name(), object.ModTime, object.ModTime)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(file.Name(), localPath)
+	if err != nil {
+		return err
+	}
+
+	markStored(localPath)
+	return nil
+}
+
+// trimLater trims the cache in the background, unless it is already being
+// trimmed.
+func trimLater() {
+	if IsLocal() {
+		return
+	}
+
+	select {
+	case trimming <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { <-trimming }()
+
+		err := trim()
+		if err != nil {
+			log.Printf("Error trimming storage cache: %v\n", err)
+		}
+	}()
+}
+
+// trim evicts the least recently used stored files until the cache is no
+// larger than storage_cache_size.
+func trim() error {
+	type cachedFile struct {
+		path     string
+		size     int64
+		lastUsed time.Time
+	}
+
+	limit := viper.GetInt64("storage_cache_size")
+	files := []cachedFile{}
+	var total int64
+
+	for _, dir := range []string{uploadsDir, blobsDir} {
+		err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			if err != nil || entry.IsDir() {
+				return err
+			}
+
+			key, ok := Key(filePath)
+			if !ok || !mirrored(key) {
+				return nil
+			}
+
+			fileInfo, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			total += fileInfo.Size()
+
+			cacheMutex.Lock()
+			lastUsed, ok := used[filePath]
+			cacheMutex.Unlock()
+			if !ok {
+				lastUsed = fileInfo.ModTime()
+			}
+
+			files = append(files, cachedFile{path: filePath, size: fileInfo.Size(), lastUsed: lastUsed})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].lastUsed.Before(files[j].lastUsed)
+	})
+
+	errs := []error{}
+	for _, file := range files {
+		if total <= limit {
+			break
+		}
+
+		evicted, err := evict(file.path)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if evicted {
+			total -= file.size
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// evict deletes a local file if it is stored and was not used recently.
+func evict(localPath string) (bool, error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if !stored[localPath] || time.Since(used[localPath]) < evictionGrace {
+		return false, nil
+	}
+
+	err := os.Remove(localPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	delete(stored, localPath)
+	delete(used, localPath)
+	return true, nil
+}
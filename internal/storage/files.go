@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The functions below address stored files by their path in data/uploads or
+// data/blobs, which every other package builds. Reads prefer a local copy and
+// fall back to the backend, and writes go to the backend.
+
+// Entry is a file or directory in a directory of the uploads or of the blobs,
+// either stored or only present locally.
+type Entry struct {
+	Name    string
+	IsDir   bool
+	ModTime time.Time
+}
+
+// Open opens a stored file.
+func Open(ctx context.Context, localPath string) (io.ReadCloser, error) {
+	key, ok := ResolvedKey(localPath)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the uploads", localPath)
+	}
+
+	for _, cachedPath := range cachedPaths(localPath, key) {
+		file, err := os.Open(cachedPath)
+		if err == nil {
+			markUsed(cachedPath)
+			return file, nil
+		}
+	}
+
+	return Backend.Get(ctx, key)
+}
+
+// ReadFile reads a stored file, fetching it into the cache.
+func ReadFile(ctx context.Context, localPath string) ([]byte, error) {
+	cachedPath, err := Fetch(ctx, localPath)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(cachedPath)
+}
+
+// WriteFile stores data as a file.
+func WriteFile(ctx context.Context, localPath string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(localPath), filepath.Base(localPath)+".tmp.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(file.Name(), localPath)
+	if err != nil {
+		return err
+	}
+
+	return Put(ctx, localPath)
+}
+
+// Stat returns the metadata of a stored file. With a remote backend, it is
+// cached for a short while.
+func Stat(ctx context.Context, localPath string) (Object, error) {
+	key, ok := ResolvedKey(localPath)
+	if !ok {
+		return Object{}, fmt.Errorf("%s is not in the uploads", localPath)
+	}
+	return stat(ctx, key)
+}
+
+// Exists reports whether a file is stored.
+func Exists(ctx context.Context, localPath string) bool {
+	_, err := Stat(ctx, localPath)
+	return err == nil
+}
+
+// Fetch returns the path of a local copy of a stored file, downloading it into
+// the cache if needed, for programs such as ffmpeg that read files.
+func Fetch(ctx context.Context, localPath string) (string, error) {
+	key, ok := ResolvedKey(localPath)
+	if !ok {
+		return "", fmt.Errorf("%s is not in the uploads", localPath)
+	}
+
+	for _, cachedPath := range cachedPaths(localPath, key) {
+		if useCached(cachedPath) {
+			return cachedPath, nil
+		}
+	}
+
+	if IsLocal() {
+		return "", ErrNotExist
+	}
+
+	cachedPath := cachePath(key)
+	err := download(ctx, key, cachedPath)
+	if err != nil {
+		return "", err
+	}
+	trimLater()
+	return cachedPath, nil
+}
+
+// Put stores a file written to data/uploads or data/blobs, after which it is
+// only a cached copy. Audio linked to a blob is stored as the blob. It does
+// nothing with the local backend, and for uploads awaiting transcoding and
+// temporary files.
+func Put(ctx context.Context, localPath string) error {
+	if IsLocal() {
+		return nil
+	}
+
+	key, ok := Key(localPath)
+	if !ok || !mirrored(key) {
+		return nil
+	}
+
+	if Resolve != nil {
+		if resolved, ok := Resolve(localPath); ok {
+			// A copy may have been stored before the file was linked.
+			err := Backend.Delete(ctx, key)
+			forgetStat(key)
+			if err != nil {
+				return err
+			}
+
+			err = Put(ctx, resolved)
+			if err != nil {
+				return err
+			}
+			markStored(localPath)
+			return nil
+		}
+	}
+
+	// Blobs are content-addressed, so a stored blob never changes.
+	if strings.HasPrefix(key, blobsPrefix) {
+		if _, err := stat(ctx, key); err == nil {
+			markStored(localPath)
+			trimLater()
+			return nil
+		}
+	}
+
+	fileInfo, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	err = put(ctx, localPath, key, fileInfo.Size())
+	forgetStat(key)
+	if err != nil {
+		return err
+	}
+
+	markStored(localPath)
+	trimLater()
+	return nil
+}
+
+// Remove deletes a file or directory, both locally and from the backend.
+func Remove(ctx context.Context, localPath string) error {
+	err := os.RemoveAll(localPath)
+	forget(localPath)
+	if err != nil || IsLocal() {
+		return err
+	}
+
+	key, ok := Key(localPath)
+	if !ok {
+		return nil
+	}
+
+	err = deletePrefix(ctx, key)
+	forgetStat(key)
+	return err
+}
+
+// ReadDir lists a directory of the uploads or of the blobs, merging the files
+// present locally with those stored, sorted by name.
+func ReadDir(ctx context.Context, dir string) ([]Entry, error) {
+	entries := map[string]Entry{}
+
+	localEntries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, localEntry := range localEntries {
+		fileInfo, err := localEntry.Info()
+		if err != nil {
+			return nil, err
+		}
+		entries[localEntry.Name()] = Entry{Name: localEntry.Name(), IsDir: localEntry.IsDir(), ModTime: fileInfo.ModTime()}
+	}
+
+	if !IsLocal() {
+		prefix, ok := dirPrefix(dir)
+		if !ok {
+			return nil, fmt.Errorf("%s is not in the uploads", dir)
+		}
+
+		objects, err := Backend.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			if prefix == "" && strings.HasPrefix(object.Key, blobsPrefix) {
+				continue
+			}
+
+			name, _, isDir := strings.Cut(strings.TrimPrefix(object.Key, prefix), "/")
+			if _, ok := entries[name]; !ok {
+				entries[name] = Entry{Name: name, IsDir: isDir, ModTime: object.ModTime}
+			}
+		}
+	}
+
+	sorted := []Entry{}
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted, nil
+}
+
+// cachedPaths returns where a local copy of a file may be, which is the file
+// itself and the file it resolves to. The file itself comes first, as it is
+// newer while it is being linked to a blob.
+func cachedPaths(localPath string, key string) []string {
+	cachedPaths := []string{localPath}
+	if resolvedPath := cachePath(key); resolvedPath != filepath.Clean(localPath) {
+		cachedPaths = append(cachedPaths, resolvedPath)
+	}
+	return cachedPaths
+}
+
+// cachePath returns the local path of a key.
+func cachePath(key string) string {
+	if strings.HasPrefix(key, blobsPrefix) {
+		return filepath.Join(blobsDir, filepath.FromSlash(strings.TrimPrefix(key, blobsPrefix)))
+	}
+	return filepath.Join(uploadsDir, filepath.FromSlash(key))
+}
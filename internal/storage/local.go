@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores files in a directory of the filesystem, and blobs, whose keys
+// start with .blobs/, in another.
+type Local struct {
+	root      string
+	blobsRoot string
+}
+
+func NewLocal(root string, blobsRoot string) *Local {
+	return &Local{root: root, blobsRoot: blobsRoot}
+}
+
+func (local *Local) path(key string) (string, error) {
+	root := local.root
+	if strings.HasPrefix(key, blobsPrefix) {
+		root, key = local.blobsRoot, strings.TrimPrefix(key, blobsPrefix)
+	}
+
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if cleaned == "." || filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+		return "", errors.New("invalid key")
+	}
+	return filepath.Join(root, cleaned), nil
+}
+
+func (local *Local) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	path, err := local.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (local *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := local.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	return file, err
+}
+
+func (local *Local) Delete(ctx context.Context, key string) error {
+	path, err := local.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (local *Local) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects := []Object{}
+	for root, rootPrefix := range map[string]string{local.root: "", local.blobsRoot: blobsPrefix} {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			if err != nil || entry.IsDir() {
+				return err
+			}
+
+			relative, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			key := rootPrefix + filepath.ToSlash(relative)
+			if !strings.HasPrefix(key, prefix) {
+				return nil
+			}
+
+			fileInfo, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			objects = append(objects, Object{Key: key, Size: fileInfo.Size(), ModTime: fileInfo.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+func (local *Local) Stat(ctx context.Context, key string) (Object, error) {
+	path, err := local.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+
+	fileInfo, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && fileInfo.IsDir()) {
+		return Object{}, ErrNotExist
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Key: key, Size: fileInfo.Size(), ModTime: fileInfo.ModTime()}, nil
+}
+
+// SignedURL returns the path the file is served at by tilawah-hub itself, as
+// local files need no signature.
+func (local *Local) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	_, err := local.Stat(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Path: "/uploads/" + key}).String(), nil
+}
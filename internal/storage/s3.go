@@ -0,0 +1,364 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// The hash of an empty payload, which every request but Put has.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+
+	// S3 does not accept signed URLs that are valid for longer than a week.
+	maxSignedURLExpiry = 7 * 24 * time.Hour
+)
+
+type S3Config struct {
+	// Endpoint is the base URL of the S3-compatible service, such as
+	// https://s3.us-east-1.amazonaws.com or http://localhost:9000.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3 stores files in a bucket of an S3-compatible service, such as Amazon S3,
+// MinIO or Garage. Requests are path-style and signed with AWS Signature
+// Version 4.
+type S3 struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+func NewS3(config S3Config) (*S3, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3_endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3_endpoint %q", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, errors.New("s3_bucket is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("s3_access_key_id and s3_secret_access_key are required")
+	}
+
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3{
+		endpoint:        endpoint,
+		region:          region,
+		bucket:          config.Bucket,
+		accessKeyID:     config.AccessKeyID,
+		secretAccessKey: config.SecretAccessKey,
+		client:          &http.Client{},
+	}, nil
+}
+
+func (s3 *S3) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	request, err := s3.newRequest(ctx, http.MethodPut, key, nil, content)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = size
+	request.Header.Set("Content-Type", contentType)
+
+	response, err := s3.do(request, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
+
+func (s3 *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, err := s3.newRequest(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s3.do(request, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (s3 *S3) Delete(ctx context.Context, key string) error {
+	request, err := s3.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := s3.do(request, emptyPayloadHash)
+	if errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
+
+func (s3 *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	type listBucketResult struct {
+		IsTruncated           bool
+		NextContinuationToken string
+		Contents              []struct {
+			Key          string
+			Size         int64
+			LastModified time.Time
+		}
+	}
+
+	objects := []Object{}
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		request, err := s3.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := s3.do(request, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding list of objects: %w", err)
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, Size: content.Size, ModTime: content.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+func (s3 *S3) Stat(ctx context.Context, key string) (Object, error) {
+	request, err := s3.newRequest(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return Object{}, err
+	}
+
+	response, err := s3.do(request, emptyPayloadHash)
+	if err != nil {
+		return Object{}, err
+	}
+	response.Body.Close()
+
+	object := Object{Key: key, Size: response.ContentLength}
+	modTime, err := http.ParseTime(response.Header.Get("Last-Modified"))
+	if err == nil {
+		object.ModTime = modTime
+	}
+	return object, nil
+}
+
+// SignedURL returns a presigned GET URL of the object. It does not check
+// whether the object exists.
+func (s3 *S3) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	expiry = min(max(expiry, time.Second), maxSignedURLExpiry)
+
+	objectURL := s3.objectURL(key, nil)
+	now := time.Now().UTC()
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", signingAlgorithm)
+	query.Set("X-Amz-Credential", s3.accessKeyID+"/"+s3.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + objectURL.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	objectURL.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + s3.signature(now, canonicalRequest)
+	return objectURL.String(), nil
+}
+
+// objectURL returns the path-style URL of a key, or of the bucket if the key
+// is empty.
+func (s3 *S3) objectURL(key string, query url.Values) *url.URL {
+	objectURL := *s3.endpoint
+
+	basePath := strings.TrimSuffix(objectURL.Path, "/")
+	objectURL.Path = basePath + "/" + s3.bucket
+	objectURL.RawPath = uriEncode(basePath, false) + "/" + uriEncode(s3.bucket, true)
+	if key != "" {
+		objectURL.Path += "/" + key
+		objectURL.RawPath += "/" + uriEncode(key, false)
+	}
+
+	objectURL.RawQuery = ""
+	if query != nil {
+		objectURL.RawQuery = canonicalQuery(query)
+	}
+	return &objectURL
+}
+
+func (s3 *S3) newRequest(ctx context.Context, method string, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, s3.objectURL(key, query).String(), body)
+}
+
+// do signs and sends a request, turning error responses into errors.
+func (s3 *S3) do(request *http.Request, payloadHash string) (*http.Response, error) {
+	s3.sign(request, payloadHash, time.Now().UTC())
+
+	response, err := s3.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 300 {
+		return response, nil
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+
+	var s3Error struct {
+		Code    string
+		Message string
+	}
+	xml.NewDecoder(io.LimitReader(response.Body, 1<<16)).Decode(&s3Error)
+	return nil, fmt.Errorf("s3 %s %s: %s: %s %s", request.Method, request.URL.Path, response.Status, s3Error.Code, s3Error.Message)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to a request,
+// signing its host, x-amz-content-sha256 and x-amz-date headers.
+func (s3 *S3) sign(request *http.Request, payloadHash string, now time.Time) {
+	request.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + now.Format(amzDateFormat) + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		canonicalQuery(request.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, s3.accessKeyID, s3.scope(now), signedHeaders, s3.signature(now, canonicalRequest),
+	))
+}
+
+func (s3 *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s3.region + "/s3/aws4_request"
+}
+
+func (s3 *S3) signature(now time.Time, canonicalRequest string) string {
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		now.Format(amzDateFormat),
+		s3.scope(now),
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s3.secretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s3.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes a query with its parameters sorted, as required for
+// signing.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and slashes
+// unless encodeSlash is set.
+func uriEncode(value string, encodeSlash bool) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '.', b == '_', b == '~':
+			builder.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			builder.WriteByte(b)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
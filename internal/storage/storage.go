@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotExist is returned when an object does not exist. It is
+// fs.ErrNotExist, so that missing objects and missing files are handled alike.
+var ErrNotExist = fs.ErrNotExist
+
+// Object describes a stored file.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage stores files under slash separated keys, such as
+// {reciter}/{slug}/{verse_key}.mp3.
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object, doing nothing if it does not exist.
+	Delete(ctx context.Context, key string) error
+	// List returns every object whose key starts with the prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Stat(ctx context.Context, key string) (Object, error)
+	// SignedURL returns a URL the object can be downloaded from until it
+	// expires.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Backend is where uploads are stored and served from.
+var Backend Storage
+
+// uploadsDir holds the uploads. With a remote backend, it is only a cache of
+// the backend, which files are fetched into for ffmpeg and written to before
+// they are stored.
+var uploadsDir = filepath.Join("data", "uploads")
+
+// blobsDir holds content-addressed audio, stored under blobsPrefix.
+var blobsDir = filepath.Join("data", "blobs")
+
+const blobsPrefix = ".blobs/"
+
+// Resolve returns the file that a file of the uploads links to, such as the
+// blob holding the audio of a verse. Files that link elsewhere are mirrored
+// as the file they link to.
+var Resolve func(localPath string) (string, bool)
+
+// Initialise creates the configured backend.
+func Initialise() error {
+	switch viper.GetString("storage") {
+	case BackendLocal:
+		Backend = NewLocal(uploadsDir, blobsDir)
+	case BackendS3:
+		s3, err := NewS3(S3Config{
+			Endpoint:        viper.GetString("s3_endpoint"),
+			Region:          viper.GetString("s3_region"),
+			Bucket:          viper.GetString("s3_bucket"),
+			AccessKeyID:     viper.GetString("s3_access_key_id"),
+			SecretAccessKey: viper.GetString("s3_secret_access_key"),
+		})
+		if err != nil {
+			return err
+		}
+		Backend = s3
+	default:
+		return fmt.Errorf("unknown storage backend %q", viper.GetString("storage"))
+	}
+
+	return nil
+}
+
+// IsLocal reports whether uploads are stored in data/uploads itself.
+func IsLocal() bool {
+	_, ok := Backend.(*Local)
+	return ok
+}
+
+// Key returns the key of a path of the uploads or of the blobs.
+func Key(localPath string) (string, bool) {
+	relative, err := filepath.Rel(uploadsDir, localPath)
+	if err == nil && relative != "." && !strings.HasPrefix(relative, "..") {
+		return filepath.ToSlash(relative), true
+	}
+
+	relative, err = filepath.Rel(blobsDir, localPath)
+	if err == nil && relative != "." && !strings.HasPrefix(relative, "..") {
+		return blobsPrefix + filepath.ToSlash(relative), true
+	}
+
+	return "", false
+}
+
+// ResolvedKey returns the key a file of the uploads is stored under, which is
+// that of the file it links to, if any.
+func ResolvedKey(localPath string) (string, bool) {
+	if Resolve != nil {
+		if resolved, ok := Resolve(localPath); ok {
+			localPath = resolved
+		}
+	}
+	return Key(localPath)
+}
+
+// Sync stores the files of a directory of the uploads or of the blobs that
+// are missing from the backend or older there, such as those written while it
+// was unreachable, and marks the others as stored so that they can be evicted.
+// Objects are never deleted, as files missing locally may have been evicted.
+// It does nothing with the local backend.
+func Sync(ctx context.Context, localPath string) error {
+	if IsLocal() {
+		return nil
+	}
+
+	prefix, ok := dirPrefix(localPath)
+	if !ok {
+		return nil
+	}
+
+	objects, err := Backend.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	remote := map[string]Object{}
+	for _, object := range objects {
+		remote[object.Key] = object
+	}
+
+	errs := []error{}
+	err = filepath.WalkDir(localPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		fileKey, _ := Key(filePath)
+		if !mirrored(fileKey) {
+			return nil
+		}
+
+		// Audio linked to a blob is stored as the blob, which is synced with
+		// the blobs.
+		if Resolve != nil {
+			if _, ok := Resolve(filePath); ok {
+				markStored(filePath)
+				return nil
+			}
+		}
+
+		fileInfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		object, exists := remote[fileKey]
+		if exists && object.Size == fileInfo.Size() && !object.ModTime.Before(fileInfo.ModTime().Truncate(time.Second)) {
+			markStored(filePath)
+			return nil
+		}
+
+		err = put(ctx, filePath, fileKey, fileInfo.Size())
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		markStored(filePath)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+// SyncLater syncs paths in the background, logging failures.
+func SyncLater(localPaths ...string) {
+	if IsLocal() {
+		return
+	}
+
+	go func() {
+		for _, localPath := range localPaths {
+			err := Sync(context.Background(), localPath)
+			if err != nil {
+				log.Printf("Error syncing %s to storage: %v\n", localPath, err)
+			}
+		}
+		trimLater()
+	}()
+}
+
+// dirPrefix returns the prefix of the keys of the files in a directory of the
+// uploads or of the blobs.
+func dirPrefix(localPath string) (string, bool) {
+	switch filepath.Clean(localPath) {
+	case filepath.Clean(uploadsDir):
+		return "", true
+	case filepath.Clean(blobsDir):
+		return blobsPrefix, true
+	}
+
+	key, ok := Key(localPath)
+	if !ok {
+		return "", false
+	}
+	return key + "/", true
+}
+
+// mirrored reports whether a file belongs in the backend. Uploads awaiting
+// transcoding and temporary files only exist locally.
+func mirrored(key string) bool {
+	base := path.Base(key)
+	switch {
+	case strings.HasSuffix(base, ".raw"), strings.Contains(base, ".tmp"):
+		return false
+	}
+	return true
+}
+
+func put(ctx context.Context, localPath string, key string, size int64) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return Backend.Put(ctx, key, file, size, ContentType(key))
+}
+
+func deletePrefix(ctx context.Context, key string) error {
+	err := Backend.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	objects, err := Backend.List(ctx, key+"/")
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, object := range objects {
+		err = Backend.Delete(ctx, object.Key)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ContentType guesses the content type of a key from its extension.
+func ContentType(key string) string {
+	switch path.Ext(key) {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".json":
+		return "application/json"
+	case ".opus", ".ogg":
+		return "audio/ogg"
+	case ".m4a":
+		return "audio/mp4"
+	case ".flac":
+		return "audio/flac"
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+	return contentType
+}
@@ -0,0 +1,300 @@
+package transcode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/blobs"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/events"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/lafzize"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/unitofwork"
+	"github.com/spf13/viper"
+)
+
+const (
+	StatusUploading = "uploading"
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+)
+
+// How often idle workers check for pending files when they have not been
+// woken up.
+const pollInterval = 5 * time.Second
+
+var wake = make(chan struct{}, 1)
+
+var ErrNotFailed = errors.New("the transcode of the recitation file has not failed")
+var ErrNoUpload = errors.New("the upload of the recitation file is gone, upload it again")
+
+// slots bounds the number of ffmpeg processes, shared by the workers and by
+// Cut.
+var slots chan struct{}
+
+// Start recovers transcodes that were in flight when the server last stopped
+// and starts the worker pool.
+func Start() {
+	err := db.Queries.RecitationFileRequeueRunningTranscodes(context.Background())
+	if err != nil {
+		log.Fatalf("Error requeueing interrupted transcodes: %v", err)
+	}
+
+	err = db.Queries.RecitationFileFailInterruptedUploads(context.Background())
+	if err != nil {
+		log.Fatalf("Error failing interrupted uploads: %v", err)
+	}
+
+	concurrency := max(viper.GetInt("transcode_concurrency"), 1)
+	slots = make(chan struct{}, concurrency)
+	for range concurrency {
+		go worker()
+	}
+}
+
+// RawPath is where the upload of a verse is kept until it is transcoded.
+func RawPath(reciter string, slug string, verseKey string) string {
+	return filepath.Join("data", "uploads", reciter, slug, verseKey+".raw")
+}
+
+// Enqueue marks a recitation file whose upload is saved to its RawPath as
+// pending as part of a unit of work, to be transcoded by the worker pool once
+// the unit is committed.
+func Enqueue(ctx context.Context, unit *unitofwork.Unit, reciter string, slug string, verseKey string) error {
+	err := unit.Queries.RecitationFileUpdateTranscodeStatus(ctx, sqlc.RecitationFileUpdateTranscodeStatusParams{
+		Reciter:         reciter,
+		Slug:            slug,
+		VerseKey:        verseKey,
+		TranscodeStatus: StatusPending,
+		TranscodeError:  "",
+	})
+	if err != nil {
+		return err
+	}
+
+	unit.AfterCommit(func() {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+
+	return nil
+}
+
+// Retry marks a recitation file whose transcode failed as pending again, to be
+// transcoded from the upload that was kept.
+func Retry(ctx context.Context, reciter string, slug string, verseKey string) error {
+	recitationFile, err := db.Queries.RecitationFileSelectRecitationFile(ctx, sqlc.RecitationFileSelectRecitationFileParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		return err
+	}
+	if recitationFile.TranscodeStatus != StatusFailed {
+		return ErrNotFailed
+	}
+
+	_, err = os.Stat(RawPath(reciter, slug, verseKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNoUpload
+	}
+	if err != nil {
+		return err
+	}
+
+	// The status is checked again, as the recitation file may have been
+	// retried concurrently.
+	requeued, err := db.Queries.RecitationFileRequeueFailedTranscode(ctx, sqlc.RecitationFileRequeueFailedTranscodeParams{
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+	if err != nil {
+		return err
+	}
+	if requeued == 0 {
+		return ErrNotFailed
+	}
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Cut writes the part of the input selected by options, such as -ss and -to,
+// to the output losslessly, waiting for a free slot of the worker pool.
+func Cut(input string, options []string, output string) error {
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("transcode_timeout"))
+	defer cancel()
+
+	return audio.Cut(ctx, input, options, output)
+}
+
+func worker() {
+	for {
+		recitationFile, err := db.Queries.RecitationFileClaimTranscode(context.Background())
+		if errors.Is(err, sql.ErrNoRows) {
+			select {
+			case <-wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("Error claiming transcode: %v\n", err)
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		process(recitationFile)
+	}
+}
+
+// process transcodes the upload of a claimed recitation file, keeping it as
+// the master if configured, and lafzizes the result if enabled. The upload is
+// kept if the transcode fails, so that it can be retried.
+func process(recitationFile sqlc.RecitationFile) {
+	reciter, slug, verseKey := recitationFile.Reciter, recitationFile.Slug, recitationFile.VerseKey
+	rawFilepath := RawPath(reciter, slug, verseKey)
+
+	err := transcode(rawFilepath, reciter, slug, verseKey)
+	if err != nil {
+		log.Printf("Error transcoding recitation file %s/%s/%s: %v\n", reciter, slug, verseKey, err)
+		fail(reciter, slug, verseKey, err)
+		return
+	}
+
+	if audio.KeepMaster() {
+		err = os.Rename(rawFilepath, audio.MasterPath(reciter, slug, verseKey))
+		if err != nil {
+			log.Printf("Error keeping master recitation file: %v\n", err)
+		}
+
+		err = storage.Put(context.Background(), audio.MasterPath(reciter, slug, verseKey))
+		if err != nil {
+			log.Printf("Error storing master recitation file: %v\n", err)
+		}
+	} else {
+		err = os.RemoveAll(rawFilepath)
+		if err != nil {
+			log.Printf("Error deleting raw recitation file: %v\n", err)
+		}
+	}
+
+	succeed(reciter, slug, verseKey)
+
+	if recitationFile.HasTimings || !lafzize.Auto(reciter, slug) {
+		return
+	}
+
+	_, err = lafzize.Schedule(context.Background(), reciter, slug, verseKey, sql.NullInt64{})
+	if err != nil {
+		log.Printf("Error scheduling automatic lafzize job for %s/%s/%s: %v\n", reciter, slug, verseKey, err)
+	}
+}
+
+// transcode runs ffmpeg once a slot is free, stores the resulting audio as
+// blobs and stores its metadata.
+func transcode(input string, reciter string, slug string, verseKey string) error {
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("transcode_timeout"))
+	defer cancel()
+
+	err := audio.Transcode(ctx, input, nil, reciter, slug, verseKey)
+	if err != nil {
+		return err
+	}
+
+	// Audio that is not adopted is neither deduplicated nor reference counted,
+	// so it fails the transcode rather than being left behind.
+	err = blobs.Adopt(context.Background(), reciter, slug, verseKey)
+	if err != nil {
+		return fmt.Errorf("error storing blobs: %w", err)
+	}
+
+	primaryFilepath, err := storage.Fetch(context.Background(), audio.PrimaryPath(reciter, slug, verseKey))
+	if err != nil {
+		log.Printf("Error fetching recitation file %s/%s/%s: %v\n", reciter, slug, verseKey, err)
+		return nil
+	}
+
+	metadata, err := audio.Probe(primaryFilepath)
+	if err != nil {
+		log.Printf("Error probing metadata of recitation file %s/%s/%s: %v\n", reciter, slug, verseKey, err)
+		return nil
+	}
+
+	err = db.Queries.RecitationFileUpdateMetadata(context.Background(), sqlc.RecitationFileUpdateMetadataParams{
+		Reciter:    reciter,
+		Slug:       slug,
+		VerseKey:   verseKey,
+		Duration:   metadata.Duration,
+		BitRate:    metadata.BitRate,
+		SampleRate: metadata.SampleRate,
+		Channels:   metadata.Channels,
+		Size:       metadata.Size,
+	})
+	if err != nil {
+		log.Printf("Error storing metadata of recitation file %s/%s/%s: %v\n", reciter, slug, verseKey, err)
+	}
+	return nil
+}
+
+func succeed(reciter string, slug string, verseKey string) {
+	err := setStatus(context.Background(), reciter, slug, verseKey, StatusDone, "")
+	if err != nil {
+		log.Printf("Error updating transcode status of %s/%s/%s: %v\n", reciter, slug, verseKey, err)
+	}
+
+	events.Publish(events.Event{
+		Type:     events.TypeTranscoded,
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+	})
+}
+
+func fail(reciter string, slug string, verseKey string, failure error) {
+	err := setStatus(context.Background(), reciter, slug, verseKey, StatusFailed, failure.Error())
+	if err != nil {
+		log.Printf("Error updating transcode status of %s/%s/%s: %v\n", reciter, slug, verseKey, err)
+	}
+
+	events.Publish(events.Event{
+		Type:     events.TypeTranscodeFailed,
+		Reciter:  reciter,
+		Slug:     slug,
+		VerseKey: verseKey,
+		Error:    failure.Error(),
+	})
+}
+
+func setStatus(ctx context.Context, reciter string, slug string, verseKey string, status string, failure string) error {
+	return db.Queries.RecitationFileUpdateTranscodeStatus(ctx, sqlc.RecitationFileUpdateTranscodeStatusParams{
+		Reciter:         reciter,
+		Slug:            slug,
+		VerseKey:        verseKey,
+		TranscodeStatus: status,
+		TranscodeError:  failure,
+	})
+}
@@ -0,0 +1,217 @@
+package unitofwork
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/sqlc"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+)
+
+// stagingDir holds the files of units of work until they are committed. It
+// is inside data so that files can be renamed into place atomically.
+var stagingDir = filepath.Join("data", "staging")
+
+// Unit groups the changes to the database and to files made by a request, so
+// that either all of them are applied or none are.
+//
+// Files are written to a staging directory and the database is changed in a
+// transaction. On Commit, files to be replaced or removed are first moved
+// aside, staged files are renamed into place and the transaction is
+// committed. If any step fails, the files moved so far are moved back. Once
+// committed, the changed files are stored in the storage backend.
+type Unit struct {
+	Queries *sqlc.Queries
+
+	tx          *sql.Tx
+	dir         string
+	writes      []write
+	removals    []string
+	afterCommit []func()
+	finished    bool
+
+	// Number of paths handed out in dir.
+	staged int
+}
+
+type write struct {
+	staged      string
+	destination string
+}
+
+// A move that has been carried out during Commit, and how to undo it.
+type move struct {
+	from string
+	to   string
+}
+
+// Begin starts a unit of work.
+func Begin(ctx context.Context) (*Unit, error) {
+	err := os.MkdirAll(stagingDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(stagingDir, "unit-*")
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &Unit{
+		Queries: db.Queries.WithTx(tx),
+		tx:      tx,
+		dir:     dir,
+	}, nil
+}
+
+// Create returns a staged file that replaces the destination on Commit. The
+// caller must close it before committing.
+func (unit *Unit) Create(destination string) (*os.File, error) {
+	file, err := os.Create(unit.stagedPath())
+	if err != nil {
+		return nil, err
+	}
+
+	unit.writes = append(unit.writes, write{staged: file.Name(), destination: destination})
+	return file, nil
+}
+
+// WriteFile stages data that replaces the destination on Commit.
+func (unit *Unit) WriteFile(destination string, data []byte) error {
+	file, err := unit.Create(destination)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Remove deletes a file or directory on Commit, if it exists.
+func (unit *Unit) Remove(destination string) {
+	unit.removals = append(unit.removals, destination)
+}
+
+// AfterCommit registers a function to run once the unit is committed.
+func (unit *Unit) AfterCommit(fn func()) {
+	unit.afterCommit = append(unit.afterCommit, fn)
+}
+
+// Commit applies the staged files and commits the transaction.
+func (unit *Unit) Commit() error {
+	if unit.finished {
+		return errors.New("the unit of work has already finished")
+	}
+	unit.finished = true
+	defer os.RemoveAll(unit.dir)
+
+	moves := []move{}
+	compensate := func(err error) error {
+		for i := len(moves) - 1; i >= 0; i-- {
+			undoErr := os.Rename(moves[i].to, moves[i].from)
+			if undoErr != nil && !errors.Is(undoErr, os.ErrNotExist) {
+				log.Printf("Error moving %s back to %s: %v\n", moves[i].to, moves[i].from, undoErr)
+			}
+		}
+		unit.tx.Rollback()
+		return err
+	}
+
+	// Existing files are moved aside rather than deleted, so that they can be
+	// restored if anything fails.
+	setAside := func(path string) error {
+		aside := unit.stagedPath()
+		err := os.Rename(path, aside)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		moves = append(moves, move{from: path, to: aside})
+		return nil
+	}
+
+	for _, removal := range unit.removals {
+		err := setAside(removal)
+		if err != nil {
+			return compensate(fmt.Errorf("error removing %s: %w", removal, err))
+		}
+	}
+
+	for _, write := range unit.writes {
+		err := os.MkdirAll(filepath.Dir(write.destination), 0755)
+		if err != nil {
+			return compensate(err)
+		}
+
+		err = setAside(write.destination)
+		if err != nil {
+			return compensate(fmt.Errorf("error replacing %s: %w", write.destination, err))
+		}
+
+		err = os.Rename(write.staged, write.destination)
+		if err != nil {
+			return compensate(fmt.Errorf("error writing %s: %w", write.destination, err))
+		}
+		moves = append(moves, move{from: write.staged, to: write.destination})
+	}
+
+	err := unit.tx.Commit()
+	if err != nil {
+		return compensate(err)
+	}
+
+	// The database is already committed, so files that fail to be stored are
+	// kept locally until storage.Sync stores them.
+	for _, removal := range unit.removals {
+		err = storage.Remove(context.Background(), removal)
+		if err != nil {
+			log.Printf("Error removing %s from storage: %v\n", removal, err)
+		}
+	}
+	for _, write := range unit.writes {
+		err = storage.Put(context.Background(), write.destination)
+		if err != nil {
+			log.Printf("Error storing %s: %v\n", write.destination, err)
+		}
+	}
+
+	for _, fn := range unit.afterCommit {
+		fn()
+	}
+	return nil
+}
+
+// Rollback discards the unit of work unless it was committed, so that it can
+// be deferred right after Begin.
+func (unit *Unit) Rollback() {
+	if unit.finished {
+		return
+	}
+	unit.finished = true
+
+	unit.tx.Rollback()
+	os.RemoveAll(unit.dir)
+}
+
+func (unit *Unit) stagedPath() string {
+	unit.staged++
+	return filepath.Join(unit.dir, strconv.Itoa(unit.staged))
+}
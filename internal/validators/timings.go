@@ -0,0 +1,76 @@
+package validators
+
+import (
+	"fmt"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/models"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+)
+
+// ValidateTiming checks that a timing has one segment per word of the verse,
+// that its segments are ordered and do not overlap, and that they lie within
+// the audio. A duration of 0 skips the last check.
+func ValidateTiming(verseKey string, timing models.Timing, duration float64) []models.SegmentError {
+	errs := []models.SegmentError{}
+
+	words, ok := quran.Words(verseKey)
+	if !ok {
+		return append(errs, models.SegmentError{
+			Segment: -1,
+			Field:   "verse_key",
+			Message: fmt.Sprintf("unknown verse %s", verseKey),
+		})
+	}
+
+	if len(timing.Segments) != len(words) {
+		errs = append(errs, models.SegmentError{
+			Segment: -1,
+			Field:   "segments",
+			Message: fmt.Sprintf("expected %d segments, one per word, got %d", len(words), len(timing.Segments)),
+		})
+	}
+
+	for i, segment := range timing.Segments {
+		if i < len(words) && segment.Text != "" && segment.Text != words[i] {
+			errs = append(errs, models.SegmentError{
+				Segment: i,
+				Field:   "text",
+				Message: fmt.Sprintf("expected word %q, got %q", words[i], segment.Text),
+			})
+		}
+
+		if segment.Start < 0 {
+			errs = append(errs, models.SegmentError{
+				Segment: i,
+				Field:   "start",
+				Message: "start must not be negative",
+			})
+		}
+
+		if segment.End < segment.Start {
+			errs = append(errs, models.SegmentError{
+				Segment: i,
+				Field:   "end",
+				Message: fmt.Sprintf("end %v is before start %v", segment.End, segment.Start),
+			})
+		}
+
+		if i > 0 && segment.Start < timing.Segments[i-1].End {
+			errs = append(errs, models.SegmentError{
+				Segment: i,
+				Field:   "start",
+				Message: fmt.Sprintf("start %v overlaps the previous segment ending at %v", segment.Start, timing.Segments[i-1].End),
+			})
+		}
+
+		if duration > 0 && segment.End > duration {
+			errs = append(errs, models.SegmentError{
+				Segment: i,
+				Field:   "end",
+				Message: fmt.Sprintf("end %v exceeds the audio duration of %v", segment.End, duration),
+			})
+		}
+	}
+
+	return errs
+}
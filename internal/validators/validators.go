@@ -37,6 +37,7 @@ func Initialise() {
 		return name
 	})
 
+	registerVerseKey()
 }
 
 func ValidateStruct(obj interface{}) error {
@@ -0,0 +1,36 @@
+package validators
+
+import (
+	"log"
+
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerVerseKey registers the `verse_key` tag, which accepts verse keys
+// of existing verses.
+func registerVerseKey() {
+	err := validate.RegisterValidation("verse_key", func(fl validator.FieldLevel) bool {
+		return quran.ValidVerseKey(fl.Field().String())
+	})
+	if err != nil {
+		log.Fatalf("Error registering verse_key validation: %v", err)
+	}
+
+	err = validate.RegisterTranslation("verse_key", translator, func(ut ut.Translator) error {
+		return ut.Add("verse_key", "{0} must be a valid verse key", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		message, _ := ut.T("verse_key", fe.Field())
+		return message
+	})
+	if err != nil {
+		log.Fatalf("Error registering verse_key translation: %v", err)
+	}
+}
+
+// ValidateVerseKey checks that a verse key refers to an existing verse.
+func ValidateVerseKey(verseKey string) error {
+	_, _, err := quran.ParseVerseKey(verseKey)
+	return err
+}
@@ -7,11 +7,17 @@ import (
 	"os"
 	"path/filepath"
 
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/audio"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/blobs"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/db"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/handlers"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/lafzize"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/middlewares"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/storage"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/transcode"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/internal/validators"
 	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/config"
+	"git.sr.ht/~rehandaphedar/tilawah-hub/pkg/quran"
 	"github.com/go-chi/chi"
 	"github.com/spf13/viper"
 )
@@ -31,6 +37,37 @@ func main() {
 	db.Connect()
 	validators.Initialise()
 
+	err = quran.LoadWords()
+	if err != nil {
+		log.Fatalf("Error loading Qur'an words: %v", err)
+	}
+
+	err = quran.LoadVerses()
+	if err != nil {
+		log.Fatalf("Error loading Qur'an verses: %v", err)
+	}
+
+	err = audio.LoadProfiles()
+	if err != nil {
+		log.Fatalf("Error loading transcoding profiles: %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "fsck" {
+		runFsck(os.Args[2:])
+		return
+	}
+
+	err = storage.Initialise()
+	if err != nil {
+		log.Fatalf("Error initialising storage: %v", err)
+	}
+	storage.SyncLater(filepath.Join("data", "uploads"), filepath.Join("data", "blobs"))
+
+	blobs.Start()
+
+	lafzize.Start()
+	transcode.Start()
+
 	router.Group(func(r chi.Router) {
 		r.Post("/register", handlers.Register)
 		r.Post("/login", handlers.Login)
@@ -50,6 +87,8 @@ func main() {
 	router.Group(func(r chi.Router) {
 		r.Get("/recitations", handlers.GetRecitations)
 		r.Get("/recitations/{reciter}/{slug}", handlers.GetRecitation)
+		r.Get("/recitations/{reciter}/{slug}/coverage", handlers.GetRecitationCoverage)
+		r.Get("/recitations/{reciter}/{slug}/archive", handlers.GetRecitationArchive)
 	})
 
 	router.Group(func(r chi.Router) {
@@ -58,29 +97,98 @@ func main() {
 		r.Post("/recitations", handlers.CreateRecitation)
 		r.Put("/recitations/{slug}", handlers.UpdateRecitation)
 		r.Delete("/recitations/{slug}", handlers.DeleteRecitation)
+		r.Post("/recitations/{slug}/import", handlers.ImportRecitation)
 	})
 
 	router.Group(func(r chi.Router) {
+		r.Use(middlewares.VerseKey)
+
 		r.Get("/recitation-files/{reciter}/{slug}", handlers.GetRecitationFiles)
 		r.Get("/recitation-files/{reciter}/{slug}/{verse_key}", handlers.GetRecitationFile)
+
+		r.Get("/audio/{reciter}/{slug}/{verse_key}", handlers.GetRecitationAudio)
+		r.Get("/audio/{reciter}/{slug}/{verse_key}/{format}", handlers.GetRecitationAudio)
+
+		r.Get("/recitation-timings/{reciter}/{slug}/{verse_key}/revisions", handlers.GetRecitationTimingRevisions)
+		r.Get("/recitation-timings/{reciter}/{slug}/{verse_key}/revisions/{id}", handlers.GetRecitationTimingRevision)
+		r.Get("/recitation-timings/{reciter}/{slug}/{verse_key}/revisions/{id}/diff/{other_id}", handlers.DiffRecitationTimingRevisions)
 	})
 
 	router.Group(func(r chi.Router) {
 		r.Use(middlewares.Auth)
+		r.Use(middlewares.VerseKey)
 
 		r.Post("/recitation-files/{slug}", handlers.CreateRecitationFile)
+		r.Post("/recitation-files/{slug}/chapter", handlers.SplitRecitationFile)
 		r.Delete("/recitation-files/{slug}/{verse_key}", handlers.DeleteRecitationFile)
+		r.Post("/recitation-files/{slug}/{verse_key}/transcode", handlers.RetryRecitationFileTranscode)
 
 		r.Post("/recitation-timings/{slug}/{verse_key}", handlers.UpdateRecitationTiming)
 		r.Delete("/recitation-timings/{slug}/{verse_key}", handlers.DeleteRecitationTiming)
+		r.Post("/recitation-timings/{slug}/{verse_key}/restore", handlers.RestoreRecitationTiming)
+		r.Post("/recitation-timings/{slug}/{verse_key}/revisions/{id}/restore", handlers.RestoreRecitationTimingRevision)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Get("/events/{reciter}/{slug}", handlers.GetRecitationEvents)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Get("/api/v4/resources/recitations", handlers.GetQuranRecitations)
+		r.Get("/api/v4/recitations/{recitation_id}/by_chapter/{chapter_number}", handlers.GetQuranAudioFilesByChapter)
+		r.Get("/api/v4/recitations/{recitation_id}/by_juz/{juz_number}", handlers.GetQuranAudioFilesByJuz)
+		r.Get("/api/v4/recitations/{recitation_id}/by_hizb/{hizb_number}", handlers.GetQuranAudioFilesByHizb)
+		r.Get("/api/v4/recitations/{recitation_id}/by_rub/{rub_el_hizb_number}", handlers.GetQuranAudioFilesByRub)
+		r.Get("/api/v4/recitations/{recitation_id}/by_page/{page_number}", handlers.GetQuranAudioFilesByPage)
+		r.Get("/api/v4/recitations/{recitation_id}/by_ayah/{ayah_key}", handlers.GetQuranAudioFilesByAyah)
+		r.Get("/api/v4/chapter_recitations/{id}", handlers.GetQuranChapterAudioFiles)
+		r.Get("/api/v4/chapter_recitations/{id}/{chapter_number}", handlers.GetQuranChapterAudioFile)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Get("/chapter-audio/{reciter}/{slug}/{chapter}", handlers.GetChapterAudio)
+		r.Get("/chapter-timings/{reciter}/{slug}/{chapter}", handlers.GetChapterTimings)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Get("/everyayah/{reciter}/{slug}/{file}", handlers.GetEveryAyahFile)
+		r.Get("/everyayah/{reciter}/{slug}/timings/{file}", handlers.GetEveryAyahTimings)
 	})
 
-	router.Handle("/uploads/*", http.StripPrefix("/uploads/", http.FileServer(http.Dir(filepath.Join("data", "uploads")))))
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.Auth)
+
+		r.Post("/everyayah/{slug}/export", handlers.ExportEveryAyah)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.Auth)
+		r.Use(middlewares.Admin)
+
+		r.Get("/admin/fsck", handlers.GetFsck)
+		r.Post("/admin/fsck/repair", handlers.RepairFsck)
+	})
+
+	if storage.IsLocal() {
+		router.Handle("/uploads/*", http.StripPrefix("/uploads/", http.FileServer(http.Dir(filepath.Join("data", "uploads")))))
+	} else {
+		router.Get("/uploads/*", handlers.GetUpload)
+	}
+
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.VerseKey)
+
+		r.Get("/lafzize/{reciter}/{slug}/{verse_key}", handlers.GetLafzizeJob)
+		r.Get("/lafzize-batches/{id}", handlers.GetLafzizeBatch)
+	})
 
 	router.Group(func(r chi.Router) {
 		r.Use(middlewares.Auth)
+		r.Use(middlewares.VerseKey)
 
+		r.Post("/lafzize/{slug}", handlers.LafzizeRecitation)
 		r.Post("/lafzize/{slug}/{verse_key}", handlers.Lafzize)
+		r.Delete("/lafzize/{slug}/{verse_key}", handlers.CancelLafzize)
 	})
 
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", viper.GetInt("port")), router))
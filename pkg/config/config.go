@@ -8,8 +8,39 @@ import (
 
 func Load() {
 	viper.SetDefault("port", 8080)
+	viper.SetDefault("aligner", "lafzize")
+	viper.SetDefault("aligner_command", []string{})
 	viper.SetDefault("lafzize_endpoint", "http://localhost:3001")
+	viper.SetDefault("lafzize_concurrency", 2)
+	viper.SetDefault("lafzize_retries", 3)
+	viper.SetDefault("lafzize_backoff", "2s")
+	viper.SetDefault("lafzize_timeout", "5m")
+	viper.SetDefault("auto_lafzize", false)
 	viper.SetDefault("disable_csrf_checks", false)
+	viper.SetDefault("transcoding_profiles", []map[string]any{
+		{
+			"name":         "mp3",
+			"extension":    "mp3",
+			"content_type": "audio/mpeg",
+			"args":         []string{"-c:a", "libmp3lame", "-b:a", "128k"},
+		},
+	})
+	viper.SetDefault("keep_master", false)
+	viper.SetDefault("transcode_concurrency", 2)
+	viper.SetDefault("transcode_timeout", "5m")
+	viper.SetDefault("storage", "local")
+	viper.SetDefault("s3_endpoint", "")
+	viper.SetDefault("s3_region", "us-east-1")
+	viper.SetDefault("s3_bucket", "")
+	viper.SetDefault("s3_access_key_id", "")
+	viper.SetDefault("s3_secret_access_key", "")
+	viper.SetDefault("s3_signed_url_expiry", "1h")
+	viper.SetDefault("storage_cache_size", int64(10<<30))
+	viper.SetDefault("blob_gc_interval", "1h")
+	viper.SetDefault("import_max_size", int64(4<<30))
+	viper.SetDefault("import_max_entry_size", int64(256<<20))
+	viper.SetDefault("import_max_total_size", int64(8<<30))
+	viper.SetDefault("admins", []string{})
 
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
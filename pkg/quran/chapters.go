@@ -0,0 +1,146 @@
+package quran
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Chapters is the number of chapters in the Qur'an.
+const Chapters = 114
+
+// Juzs is the number of juzs in the Qur'an.
+const Juzs = 30
+
+// Verses is the number of verses in the Qur'an.
+const Verses = 6236
+
+// Hizbs is the number of hizbs in the Qur'an.
+const Hizbs = 60
+
+// RubElHizbs is the number of rub el hizbs in the Qur'an.
+const RubElHizbs = 240
+
+// Pages is the number of pages of the Madani mushaf.
+const Pages = 604
+
+// verseCounts holds the number of verses of each chapter, indexed from 0.
+var verseCounts = [Chapters]int{
+	7, 286, 200, 176, 120, 165, 206, 75, 129, 109,
+	123, 111, 43, 52, 99, 128, 111, 110, 98, 135,
+	112, 78, 118, 64, 77, 227, 93, 88, 69, 60,
+	34, 30, 73, 54, 45, 83, 182, 88, 75, 85,
+	54, 53, 89, 59, 37, 35, 38, 29, 18, 45,
+	60, 49, 62, 55, 78, 96, 29, 22, 24, 13,
+	14, 11, 11, 18, 12, 12, 30, 52, 52, 44,
+	28, 28, 20, 56, 40, 31, 50, 40, 46, 42,
+	29, 19, 36, 25, 22, 17, 19, 26, 30, 20,
+	15, 21, 11, 8, 8, 19, 5, 8, 8, 11,
+	11, 8, 3, 9, 5, 4, 7, 3, 6, 3,
+	5, 4, 5, 6,
+}
+
+// juzStarts holds the chapter and verse each juz starts at, indexed from 0.
+var juzStarts = [Juzs][2]int{
+	{1, 1}, {2, 142}, {2, 253}, {3, 93}, {4, 24},
+	{4, 148}, {5, 82}, {6, 111}, {7, 88}, {8, 41},
+	{9, 93}, {11, 6}, {12, 53}, {15, 1}, {17, 1},
+	{18, 75}, {21, 1}, {23, 1}, {25, 21}, {27, 56},
+	{29, 46}, {33, 31}, {36, 28}, {39, 32}, {41, 47},
+	{46, 1}, {51, 31}, {58, 1}, {67, 1}, {78, 1},
+}
+
+// VerseCount returns the number of verses of a chapter, or 0 if there is no
+// such chapter.
+func VerseCount(chapter int) int {
+	if chapter < 1 || chapter > Chapters {
+		return 0
+	}
+	return verseCounts[chapter-1]
+}
+
+// ParseVerseKey parses a verse key of the form `chapter:verse`, without
+// leading zeros, and checks that the verse exists.
+func ParseVerseKey(verseKey string) (int, int, error) {
+	chapterString, verseString, found := strings.Cut(verseKey, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid verse key %q", verseKey)
+	}
+
+	chapter, err := strconv.Atoi(chapterString)
+	if err != nil || strconv.Itoa(chapter) != chapterString {
+		return 0, 0, fmt.Errorf("invalid verse key %q", verseKey)
+	}
+
+	verse, err := strconv.Atoi(verseString)
+	if err != nil || strconv.Itoa(verse) != verseString {
+		return 0, 0, fmt.Errorf("invalid verse key %q", verseKey)
+	}
+
+	if chapter < 1 || chapter > Chapters {
+		return 0, 0, fmt.Errorf("invalid verse key %q: there is no chapter %d", verseKey, chapter)
+	}
+
+	if verse < 1 || verse > VerseCount(chapter) {
+		return 0, 0, fmt.Errorf("invalid verse key %q: chapter %d has %d verses", verseKey, chapter, VerseCount(chapter))
+	}
+
+	return chapter, verse, nil
+}
+
+// ValidVerseKey reports whether a verse key refers to an existing verse.
+func ValidVerseKey(verseKey string) bool {
+	_, _, err := ParseVerseKey(verseKey)
+	return err == nil
+}
+
+// VerseKey formats a chapter and verse as a verse key.
+func VerseKey(chapter int, verse int) string {
+	return fmt.Sprintf("%d:%d", chapter, verse)
+}
+
+// VerseRange formats the verses of a chapter from one verse to another, such
+// as `1:1-7`.
+func VerseRange(chapter int, from int, to int) string {
+	return fmt.Sprintf("%d:%d-%d", chapter, from, to)
+}
+
+// ParseVerseRange parses a range formatted by VerseRange, or a single verse
+// key, and checks that its verses exist and are in order.
+func ParseVerseRange(verseRange string) (int, int, int, error) {
+	fromKey, toString, found := strings.Cut(verseRange, "-")
+	chapter, from, err := ParseVerseKey(fromKey)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if !found {
+		return chapter, from, from, nil
+	}
+
+	_, to, err := ParseVerseKey(fmt.Sprintf("%d:%s", chapter, toString))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if to < from {
+		return 0, 0, 0, fmt.Errorf("invalid verse range %q: %d is before %d", verseRange, to, from)
+	}
+
+	return chapter, from, to, nil
+}
+
+// Juz returns the juz a verse is in.
+func Juz(chapter int, verse int) int {
+	juz := 1
+	for i, start := range juzStarts {
+		if chapter > start[0] || (chapter == start[0] && verse >= start[1]) {
+			juz = i + 1
+		}
+	}
+	return juz
+}
+
+// JuzStart returns the chapter and verse a juz starts at.
+func JuzStart(juz int) (int, int) {
+	start := juzStarts[juz-1]
+	return start[0], start[1]
+}
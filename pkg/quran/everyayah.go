@@ -0,0 +1,39 @@
+package quran
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// EveryAyahName returns the EveryAyah file name of a verse without its
+// extension, the chapter and verse zero padded to three digits each, for
+// example `001001` for 1:1.
+func EveryAyahName(chapter int, verse int) string {
+	return fmt.Sprintf("%03d%03d", chapter, verse)
+}
+
+// ParseEveryAyahName parses an EveryAyah file name without its extension
+// into the verse key it refers to.
+func ParseEveryAyahName(name string) (string, error) {
+	if len(name) != 6 {
+		return "", fmt.Errorf("invalid EveryAyah name %q", name)
+	}
+
+	chapter, err := strconv.Atoi(name[:3])
+	if err != nil {
+		return "", fmt.Errorf("invalid EveryAyah name %q", name)
+	}
+
+	verse, err := strconv.Atoi(name[3:])
+	if err != nil {
+		return "", fmt.Errorf("invalid EveryAyah name %q", name)
+	}
+
+	verseKey := VerseKey(chapter, verse)
+	_, _, err = ParseVerseKey(verseKey)
+	if err != nil {
+		return "", err
+	}
+
+	return verseKey, nil
+}
@@ -0,0 +1,109 @@
+//go:build ignore
+
+// generate.go downloads the data embedded in the quran package from the
+// quran.com API. Run it with `go generate ./pkg/quran`.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const api = "https://api.quran.com/api/v4"
+
+func main() {
+	err := generateWords()
+	if err != nil {
+		log.Fatalf("Error generating the text of the Qur'an: %v", err)
+	}
+
+	err = generateVerses()
+	if err != nil {
+		log.Fatalf("Error generating the divisions of the Qur'an: %v", err)
+	}
+}
+
+// generateWords saves the text_uthmani of every verse.
+func generateWords() error {
+	var uthmani struct {
+		Verses []struct {
+			VerseKey    string `json:"verse_key"`
+			TextUthmani string `json:"text_uthmani"`
+		} `json:"verses"`
+	}
+	err := get(api+"/quran/verses/uthmani", &uthmani)
+	if err != nil {
+		return err
+	}
+	if len(uthmani.Verses) != 6236 {
+		return fmt.Errorf("expected 6236 verses, got %d", len(uthmani.Verses))
+	}
+
+	return save("uthmani.json", uthmani)
+}
+
+type verse struct {
+	VerseKey        string `json:"verse_key"`
+	JuzNumber       int    `json:"juz_number"`
+	HizbNumber      int    `json:"hizb_number"`
+	RubElHizbNumber int    `json:"rub_el_hizb_number"`
+	RukuNumber      int    `json:"ruku_number"`
+	PageNumber      int    `json:"page_number"`
+}
+
+// generateVerses saves the juz, hizb, rub el hizb, ruku and page of every
+// verse, going through the mushaf page by page.
+func generateVerses() error {
+	verses := []verse{}
+	for page := 1; page <= 604; page++ {
+		for current := 1; current != 0; {
+			var byPage struct {
+				Verses     []verse `json:"verses"`
+				Pagination struct {
+					NextPage int `json:"next_page"`
+				} `json:"pagination"`
+			}
+			err := get(fmt.Sprintf("%s/verses/by_page/%d?per_page=50&page=%d", api, page, current), &byPage)
+			if err != nil {
+				return err
+			}
+
+			verses = append(verses, byPage.Verses...)
+			current = byPage.Pagination.NextPage
+		}
+	}
+	if len(verses) != 6236 {
+		return fmt.Errorf("expected 6236 verses, got %d", len(verses))
+	}
+
+	return save("verses.json", map[string][]verse{"verses": verses})
+}
+
+func get(url string, v any) error {
+	response, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1<<10))
+		return fmt.Errorf("GET %s: %s: %s", url, response.Status, body)
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+func save(name string, v any) error {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join("data", name), jsonData, 0644)
+}
@@ -0,0 +1,75 @@
+package quran
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Verse holds the divisions of the Madani mushaf a verse falls in.
+type Verse struct {
+	VerseKey        string `json:"verse_key"`
+	JuzNumber       int    `json:"juz_number"`
+	HizbNumber      int    `json:"hizb_number"`
+	RubElHizbNumber int    `json:"rub_el_hizb_number"`
+	RukuNumber      int    `json:"ruku_number"`
+	PageNumber      int    `json:"page_number"`
+}
+
+var verses map[string]Verse
+var versesOnce sync.Once
+var versesErr error
+
+type mushafVerses struct {
+	Verses []Verse `json:"verses"`
+}
+
+// LoadVerses reads the embedded hizb, rub el hizb, ruku and page of every
+// verse, in the format returned by the quran.com API's verse endpoints. It is
+// done once, on first use.
+func LoadVerses() error {
+	versesOnce.Do(func() {
+		jsonData, err := data.ReadFile("data/verses.json")
+		if err != nil {
+			versesErr = fmt.Errorf("the divisions of the Qur'an are not embedded, run go generate ./pkg/quran: %w", err)
+			return
+		}
+
+		var loaded mushafVerses
+		err = json.Unmarshal(jsonData, &loaded)
+		if err != nil {
+			versesErr = err
+			return
+		}
+		if len(loaded.Verses) != Verses {
+			versesErr = fmt.Errorf("expected the divisions of %d verses, got %d", Verses, len(loaded.Verses))
+			return
+		}
+
+		verses = make(map[string]Verse, len(loaded.Verses))
+		for _, verse := range loaded.Verses {
+			verses[verse.VerseKey] = verse
+		}
+	})
+
+	return versesErr
+}
+
+// VerseInfo returns the divisions a verse falls in.
+func VerseInfo(verseKey string) (Verse, error) {
+	chapter, verse, err := ParseVerseKey(verseKey)
+	if err != nil {
+		return Verse{}, err
+	}
+
+	err = LoadVerses()
+	if err != nil {
+		return Verse{}, err
+	}
+
+	info := verses[verseKey]
+	info.VerseKey = verseKey
+	info.JuzNumber = Juz(chapter, verse)
+
+	return info, nil
+}
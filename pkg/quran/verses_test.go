@@ -0,0 +1,29 @@
+package quran
+
+import (
+	"testing"
+)
+
+func TestLoadVerses(t *testing.T) {
+	err := LoadVerses()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []Verse{
+		{VerseKey: "1:1", JuzNumber: 1, HizbNumber: 1, RubElHizbNumber: 1, RukuNumber: 1, PageNumber: 1},
+		{VerseKey: "2:142", JuzNumber: 2, HizbNumber: 3, RubElHizbNumber: 9, PageNumber: 22},
+		{VerseKey: "114:6", JuzNumber: 30, HizbNumber: 60, RubElHizbNumber: 240, PageNumber: 604},
+	} {
+		info, err := VerseInfo(expected.VerseKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected.RukuNumber == 0 {
+			expected.RukuNumber = info.RukuNumber
+		}
+		if info != expected {
+			t.Errorf("expected %+v, got %+v", expected, info)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package quran
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//go:generate go run generate.go
+
+// data holds the text and divisions of every verse, as generated from the
+// quran.com API by generate.go.
+//
+//go:embed data
+var data embed.FS
+
+var words map[string][]string
+var wordsOnce sync.Once
+var wordsErr error
+
+type uthmaniVerses struct {
+	Verses []struct {
+		VerseKey    string `json:"verse_key"`
+		TextUthmani string `json:"text_uthmani"`
+	} `json:"verses"`
+}
+
+// LoadWords splits the embedded text of every verse, in the format returned by
+// the quran.com API's `/quran/verses/uthmani` endpoint, into the words timings
+// must follow. It is done once, on first use.
+func LoadWords() error {
+	wordsOnce.Do(func() {
+		jsonData, err := data.ReadFile("data/uthmani.json")
+		if err != nil {
+			wordsErr = fmt.Errorf("the text of the Qur'an is not embedded, run go generate ./pkg/quran: %w", err)
+			return
+		}
+
+		var verses uthmaniVerses
+		err = json.Unmarshal(jsonData, &verses)
+		if err != nil {
+			wordsErr = err
+			return
+		}
+		if len(verses.Verses) != Verses {
+			wordsErr = fmt.Errorf("expected the text of %d verses, got %d", Verses, len(verses.Verses))
+			return
+		}
+
+		words = make(map[string][]string, len(verses.Verses))
+		for _, verse := range verses.Verses {
+			words[verse.VerseKey] = strings.Fields(verse.TextUthmani)
+		}
+	})
+
+	return wordsErr
+}
+
+// Words returns the words of a verse as per its text_uthmani, and whether
+// the verse exists.
+func Words(verseKey string) ([]string, bool) {
+	LoadWords()
+	verseWords, ok := words[verseKey]
+	return verseWords, ok
+}
@@ -0,0 +1,28 @@
+package quran
+
+import (
+	"testing"
+)
+
+func TestLoadWords(t *testing.T) {
+	err := LoadWords()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for chapter := 1; chapter <= Chapters; chapter++ {
+		for verse := 1; verse <= VerseCount(chapter); verse++ {
+			verseWords, ok := Words(VerseKey(chapter, verse))
+			if !ok || len(verseWords) == 0 {
+				t.Errorf("no words for %s", VerseKey(chapter, verse))
+			}
+		}
+	}
+
+	for verseKey, count := range map[string]int{"1:1": 4, "112:1": 4} {
+		verseWords, _ := Words(verseKey)
+		if len(verseWords) != count {
+			t.Errorf("expected %d words for %s, got %q", count, verseKey, verseWords)
+		}
+	}
+}